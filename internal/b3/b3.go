@@ -0,0 +1,207 @@
+package b3
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// B3 headers from: https://github.com/openzipkin/b3-propagation
+const (
+	Header             = "b3"
+	TraceIDHeader      = "x-b3-traceid"
+	SpanIDHeader       = "x-b3-spanid"
+	ParentSpanIDHeader = "x-b3-parentspanid"
+	SampledHeader      = "x-b3-sampled"
+	FlagsHeader        = "x-b3-flags"
+)
+
+// sampling states
+const (
+	sampled    = "1"
+	notSampled = "0"
+	debug      = "d"
+)
+
+// errors returned when parsing B3 headers
+var (
+	ErrInvalidHeader       = errors.New("invalid b3 header")
+	ErrInvalidTraceID      = errors.New("invalid b3 trace id")
+	ErrInvalidSpanID       = errors.New("invalid b3 span id")
+	ErrInvalidParentSpanID = errors.New("invalid b3 parent span id")
+	ErrInvalidSampled      = errors.New("invalid b3 sampling state")
+	ErrInvalidFlags        = errors.New("invalid b3 flags")
+)
+
+// ParseSingleHeader parses a b3 header: {TraceId}-{SpanId}[-{SamplingState}[-{ParentSpanId}]];
+// a header holding only a sampling state carries no span context and returns an invalid one
+func ParseSingleHeader(s string) (trace.SpanContext, error) {
+	parts := strings.Split(s, "-")
+	if len(parts) == 1 {
+		if _, err := parseSamplingState(parts[0]); err != nil {
+			return trace.SpanContext{}, err
+		}
+		return trace.SpanContext{}, nil
+	}
+	if len(parts) > 4 {
+		return trace.SpanContext{}, fmt.Errorf("%w: '%s'", ErrInvalidHeader, s)
+	}
+
+	cfg := trace.SpanContextConfig{Remote: true}
+	var err error
+	if cfg.TraceID, err = parseTraceID(parts[0]); err != nil {
+		return trace.SpanContext{}, err
+	}
+	if cfg.SpanID, err = parseSpanID(parts[1], ErrInvalidSpanID); err != nil {
+		return trace.SpanContext{}, err
+	}
+	if len(parts) > 2 {
+		if cfg.TraceFlags, err = parseSamplingState(parts[2]); err != nil {
+			return trace.SpanContext{}, err
+		}
+	}
+	if len(parts) > 3 {
+		if _, err = parseSpanID(parts[3], ErrInvalidParentSpanID); err != nil {
+			return trace.SpanContext{}, err
+		}
+	}
+	return trace.NewSpanContext(cfg), nil
+}
+
+// ParseMultiHeaders parses the X-B3-TraceId, X-B3-SpanId, X-B3-Sampled and X-B3-Flags
+// header values; a debug flag implies the trace is sampled
+func ParseMultiHeaders(traceID, spanID, sampledValue, flags string) (trace.SpanContext, error) {
+	cfg := trace.SpanContextConfig{Remote: true}
+	var err error
+	if cfg.TraceID, err = parseTraceID(traceID); err != nil {
+		return trace.SpanContext{}, err
+	}
+	if cfg.SpanID, err = parseSpanID(spanID, ErrInvalidSpanID); err != nil {
+		return trace.SpanContext{}, err
+	}
+	switch sampledValue {
+	case "", notSampled, "false":
+	case sampled, "true":
+		cfg.TraceFlags = trace.FlagsSampled
+	default:
+		return trace.SpanContext{}, fmt.Errorf("%w: '%s'", ErrInvalidSampled, sampledValue)
+	}
+	switch flags {
+	case "", "0":
+	case "1":
+		cfg.TraceFlags = trace.FlagsSampled
+	default:
+		return trace.SpanContext{}, fmt.Errorf("%w: '%s'", ErrInvalidFlags, flags)
+	}
+	return trace.NewSpanContext(cfg), nil
+}
+
+// parseTraceID accepts 64-bit and 128-bit trace ids; a 64-bit id is left-padded with zeros
+func parseTraceID(s string) (trace.TraceID, error) {
+	var id trace.TraceID
+	if len(s) == 16 {
+		s = strings.Repeat("0", 16) + s
+	}
+	if err := decodeLowerHex(id[:], s); err != nil || !id.IsValid() {
+		return trace.TraceID{}, fmt.Errorf("%w: '%s'", ErrInvalidTraceID, s)
+	}
+	return id, nil
+}
+
+func parseSpanID(s string, invalid error) (trace.SpanID, error) {
+	var id trace.SpanID
+	if err := decodeLowerHex(id[:], s); err != nil || !id.IsValid() {
+		return trace.SpanID{}, fmt.Errorf("%w: '%s'", invalid, s)
+	}
+	return id, nil
+}
+
+func parseSamplingState(s string) (trace.TraceFlags, error) {
+	switch s {
+	case sampled, debug:
+		return trace.FlagsSampled, nil
+	case notSampled:
+		return 0, nil
+	default:
+		return 0, fmt.Errorf("%w: '%s'", ErrInvalidSampled, s)
+	}
+}
+
+// decodeLowerHex fills dst from exactly len(dst) bytes of lowercase hex
+func decodeLowerHex(dst []byte, s string) error {
+	if len(s) != 2*len(dst) || strings.ToLower(s) != s {
+		return fmt.Errorf("want %d lowercase hex digits", 2*len(dst))
+	}
+	_, err := hex.Decode(dst, []byte(s))
+	return err
+}
+
+func samplingState(sc trace.SpanContext) string {
+	if sc.IsSampled() {
+		return sampled
+	}
+	return notSampled
+}
+
+// SingleHeader propagates span context in the single b3 header
+type SingleHeader struct{}
+
+var _ propagation.TextMapPropagator = SingleHeader{}
+
+// Inject implements propagation.TextMapPropagator
+func (SingleHeader) Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return
+	}
+	carrier.Set(Header, fmt.Sprintf("%s-%s-%s", sc.TraceID(), sc.SpanID(), samplingState(sc)))
+}
+
+// Extract implements propagation.TextMapPropagator; an invalid header is ignored
+func (SingleHeader) Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	sc, err := ParseSingleHeader(carrier.Get(Header))
+	if err != nil || !sc.IsValid() {
+		return ctx
+	}
+	return trace.ContextWithRemoteSpanContext(ctx, sc)
+}
+
+// Fields implements propagation.TextMapPropagator
+func (SingleHeader) Fields() []string {
+	return []string{Header}
+}
+
+// MultipleHeader propagates span context in the X-B3-* headers
+type MultipleHeader struct{}
+
+var _ propagation.TextMapPropagator = MultipleHeader{}
+
+// Inject implements propagation.TextMapPropagator
+func (MultipleHeader) Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return
+	}
+	carrier.Set(TraceIDHeader, sc.TraceID().String())
+	carrier.Set(SpanIDHeader, sc.SpanID().String())
+	carrier.Set(SampledHeader, samplingState(sc))
+}
+
+// Extract implements propagation.TextMapPropagator; invalid headers are ignored
+func (MultipleHeader) Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	sc, err := ParseMultiHeaders(carrier.Get(TraceIDHeader), carrier.Get(SpanIDHeader), carrier.Get(SampledHeader), carrier.Get(FlagsHeader))
+	if err != nil {
+		return ctx
+	}
+	return trace.ContextWithRemoteSpanContext(ctx, sc)
+}
+
+// Fields implements propagation.TextMapPropagator
+func (MultipleHeader) Fields() []string {
+	return []string{TraceIDHeader, SpanIDHeader, SampledHeader, FlagsHeader}
+}
@@ -0,0 +1,138 @@
+package b3
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	testTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	testSpanID  = "00f067aa0ba902b7"
+)
+
+func TestParseSingleHeader(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		wantTraceID string
+		wantSampled bool
+		wantErr     error
+	}{
+		{name: "trace and span", in: testTraceID + "-" + testSpanID, wantTraceID: testTraceID},
+		{name: "sampled", in: testTraceID + "-" + testSpanID + "-1", wantTraceID: testTraceID, wantSampled: true},
+		{name: "debug", in: testTraceID + "-" + testSpanID + "-d", wantTraceID: testTraceID, wantSampled: true},
+		{name: "with parent", in: testTraceID + "-" + testSpanID + "-0-05e3ac9a4f6e3b90", wantTraceID: testTraceID},
+		{name: "64-bit trace id", in: "a3ce929d0e0e4736-" + testSpanID + "-1", wantTraceID: "0000000000000000a3ce929d0e0e4736", wantSampled: true},
+		{name: "sampling state only", in: "0"},
+		{name: "invalid sampling state only", in: "x", wantErr: ErrInvalidSampled},
+		{name: "uppercase trace id", in: "4BF92F3577B34DA6A3CE929D0E0E4736-" + testSpanID, wantErr: ErrInvalidTraceID},
+		{name: "all-zero trace id", in: "00000000000000000000000000000000-" + testSpanID, wantErr: ErrInvalidTraceID},
+		{name: "short span id", in: testTraceID + "-00f067aa", wantErr: ErrInvalidSpanID},
+		{name: "invalid sampling state", in: testTraceID + "-" + testSpanID + "-2", wantErr: ErrInvalidSampled},
+		{name: "invalid parent", in: testTraceID + "-" + testSpanID + "-1-xyz", wantErr: ErrInvalidParentSpanID},
+		{name: "too many fields", in: testTraceID + "-" + testSpanID + "-1-05e3ac9a4f6e3b90-x", wantErr: ErrInvalidHeader},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc, err := ParseSingleHeader(tt.in)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseSingleHeader() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if tt.wantTraceID == "" {
+				if sc.IsValid() {
+					t.Errorf("ParseSingleHeader() = %v, want an invalid span context", sc)
+				}
+				return
+			}
+			if got := sc.TraceID().String(); got != tt.wantTraceID {
+				t.Errorf("trace id = %s, want %s", got, tt.wantTraceID)
+			}
+			if got := sc.SpanID().String(); got != testSpanID {
+				t.Errorf("span id = %s, want %s", got, testSpanID)
+			}
+			if sc.IsSampled() != tt.wantSampled {
+				t.Errorf("sampled = %v, want %v", sc.IsSampled(), tt.wantSampled)
+			}
+		})
+	}
+}
+
+func TestParseMultiHeaders(t *testing.T) {
+	tests := []struct {
+		name        string
+		traceID     string
+		spanID      string
+		sampled     string
+		flags       string
+		wantSampled bool
+		wantErr     error
+	}{
+		{name: "unsampled", traceID: testTraceID, spanID: testSpanID},
+		{name: "sampled", traceID: testTraceID, spanID: testSpanID, sampled: "1", wantSampled: true},
+		{name: "legacy sampled", traceID: testTraceID, spanID: testSpanID, sampled: "true", wantSampled: true},
+		{name: "debug", traceID: testTraceID, spanID: testSpanID, flags: "1", wantSampled: true},
+		{name: "missing trace id", spanID: testSpanID, wantErr: ErrInvalidTraceID},
+		{name: "missing span id", traceID: testTraceID, wantErr: ErrInvalidSpanID},
+		{name: "invalid sampled", traceID: testTraceID, spanID: testSpanID, sampled: "yes", wantErr: ErrInvalidSampled},
+		{name: "invalid flags", traceID: testTraceID, spanID: testSpanID, flags: "2", wantErr: ErrInvalidFlags},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc, err := ParseMultiHeaders(tt.traceID, tt.spanID, tt.sampled, tt.flags)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseMultiHeaders() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !sc.IsValid() || !sc.IsRemote() {
+				t.Errorf("ParseMultiHeaders() = %v, want a valid remote span context", sc)
+			}
+			if sc.IsSampled() != tt.wantSampled {
+				t.Errorf("sampled = %v, want %v", sc.IsSampled(), tt.wantSampled)
+			}
+		})
+	}
+}
+
+func TestPropagators_roundTrip(t *testing.T) {
+	traceID, _ := trace.TraceIDFromHex(testTraceID)
+	spanID, _ := trace.SpanIDFromHex(testSpanID)
+	sc := trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID, TraceFlags: trace.FlagsSampled})
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+
+	tests := []struct {
+		name       string
+		propagator propagation.TextMapPropagator
+		want       map[string]string
+	}{
+		{name: "single", propagator: SingleHeader{}, want: map[string]string{Header: testTraceID + "-" + testSpanID + "-1"}},
+		{name: "multiple", propagator: MultipleHeader{}, want: map[string]string{TraceIDHeader: testTraceID, SpanIDHeader: testSpanID, SampledHeader: "1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			carrier := propagation.MapCarrier{}
+			tt.propagator.Inject(ctx, carrier)
+			if len(carrier) != len(tt.want) {
+				t.Errorf("Inject() = %v, want %v", carrier, tt.want)
+			}
+			for k, v := range tt.want {
+				if carrier[k] != v {
+					t.Errorf("Inject() %s = %q, want %q", k, carrier[k], v)
+				}
+			}
+
+			got := trace.SpanContextFromContext(tt.propagator.Extract(context.Background(), carrier))
+			if !got.Equal(sc.WithRemote(true)) {
+				t.Errorf("Extract() = %v, want %v", got, sc)
+			}
+		})
+	}
+}
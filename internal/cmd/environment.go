@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+)
+
+// OpenTelemetry SDK environment variables: https://opentelemetry.io/docs/reference/specification/sdk-environment-variables/
+const (
+	envOTLPEndpoint       = "OTEL_EXPORTER_OTLP_ENDPOINT"
+	envOTLPTracesEndpoint = "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"
+	envOTLPProtocol       = "OTEL_EXPORTER_OTLP_PROTOCOL"
+	envOTLPTracesProtocol = "OTEL_EXPORTER_OTLP_TRACES_PROTOCOL"
+	envOTLPHeaders        = "OTEL_EXPORTER_OTLP_HEADERS"
+	envOTLPTracesHeaders  = "OTEL_EXPORTER_OTLP_TRACES_HEADERS"
+	envPropagators        = "OTEL_PROPAGATORS"
+
+	otlpProtocolGrpc         = "grpc"
+	otlpProtocolHttpProtobuf = "http/protobuf"
+	otlpTracesPath           = "/v1/traces"
+)
+
+// completeFromEnvironment fills in resource settings and propagators which were not set by
+// flags from the standard OTEL_SERVICE_NAME, OTEL_RESOURCE_ATTRIBUTES and OTEL_PROPAGATORS
+// environment variables
+func (o *RunOptions) completeFromEnvironment(flags *pflag.FlagSet) error {
+	// resource.Environment() reads OTEL_RESOURCE_ATTRIBUTES and lets OTEL_SERVICE_NAME override service.name
+	envAttributes := make(map[attribute.Key]string)
+	for _, kv := range resource.Environment().Attributes() {
+		envAttributes[kv.Key] = kv.Value.Emit()
+	}
+	for _, r := range []struct {
+		flagName string
+		key      attribute.Key
+		target   *string
+	}{
+		{flagName: "service", key: semconv.ServiceNameKey, target: &o.ServiceName},
+		{flagName: "service-version", key: semconv.ServiceVersionKey, target: &o.ServiceVersion},
+		{flagName: "deployment-environment", key: semconv.DeploymentEnvironmentKey, target: &o.DeploymentEnvironment},
+	} {
+		if flags.Changed(r.flagName) {
+			continue
+		}
+		if v, ok := envAttributes[r.key]; ok {
+			*r.target = v
+		}
+	}
+
+	if v := os.Getenv(envPropagators); v != "" && !flags.Changed("propagators") {
+		o.Propagators = strings.Split(v, ",")
+		for i, name := range o.Propagators {
+			o.Propagators[i] = strings.TrimSpace(name)
+		}
+	}
+
+	return nil
+}
+
+// completeFromEnvironment fills in the endpoint (when no endpoint flag was given) and headers
+// from the standard OTEL_EXPORTER_OTLP_* environment variables
+func (o *OTLPExporterOptions) completeFromEnvironment() error {
+	if o.TraceOLTPHttpEndpoint == "" && o.TraceOLTPGrpcEndpoint == "" {
+		if err := o.completeTraceEndpointFromEnvironment(); err != nil {
+			return err
+		}
+	}
+
+	if o.TraceHeaders == nil {
+		o.TraceHeaders = make(map[string]string)
+	}
+	// signal-specific headers override the generic ones
+	for _, key := range []string{envOTLPHeaders, envOTLPTracesHeaders} {
+		for k, v := range parseOTLPHeaders(os.Getenv(key)) {
+			o.TraceHeaders[k] = v
+		}
+	}
+	return nil
+}
+
+// completeTraceEndpointFromEnvironment selects an OTLP exporter from the OTEL_EXPORTER_OTLP_* endpoint and protocol
+func (o *OTLPExporterOptions) completeTraceEndpointFromEnvironment() error {
+	protocol := strings.TrimSpace(os.Getenv(envOTLPTracesProtocol))
+	if protocol == "" {
+		protocol = strings.TrimSpace(os.Getenv(envOTLPProtocol))
+	}
+	if protocol == "" {
+		protocol = otlpProtocolHttpProtobuf
+	}
+
+	endpoint := strings.TrimSpace(os.Getenv(envOTLPTracesEndpoint))
+	if endpoint == "" {
+		endpoint = strings.TrimSpace(os.Getenv(envOTLPEndpoint))
+		if endpoint != "" && protocol == otlpProtocolHttpProtobuf {
+			// a generic http endpoint is the base URL for all signals; per-signal endpoints are used as-is
+			endpoint = strings.TrimSuffix(endpoint, "/") + otlpTracesPath
+		}
+	}
+	if endpoint == "" {
+		return nil
+	}
+
+	switch protocol {
+	case otlpProtocolHttpProtobuf:
+		o.TraceOLTPHttpEndpoint = endpoint
+	case otlpProtocolGrpc:
+		o.TraceOLTPGrpcEndpoint = endpoint
+	default:
+		return fmt.Errorf("unsupported OTLP protocol '%s': must be one of '%s' or '%s'", protocol, otlpProtocolHttpProtobuf, otlpProtocolGrpc)
+	}
+	return nil
+}
+
+// parseOTLPHeaders parses the W3C correlation-context style key=value,key=value format used by OTEL_EXPORTER_OTLP_HEADERS
+func parseOTLPHeaders(s string) map[string]string {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		k, err := url.QueryUnescape(strings.TrimSpace(kv[0]))
+		if err != nil || k == "" {
+			continue
+		}
+		v, err := url.QueryUnescape(strings.TrimSpace(kv[1]))
+		if err != nil {
+			continue
+		}
+		headers[k] = v
+	}
+	return headers
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// attribute keys describing how the wrapped command exited
+const (
+	processExitCodeKey   = attribute.Key("process.exit_code")
+	processExitSignalKey = attribute.Key("process.exit_signal")
+)
+
+// signalExitCodeBase follows the shell convention of reporting a death by signal N as exit code 128+N
+const signalExitCodeBase = 128
+
+// shell-style exit codes for a command which could not be started
+const (
+	notExecutableExitCode = 126
+	notFoundExitCode      = 127
+)
+
+// signalNames names the signals which commonly terminate a wrapped command
+var signalNames = map[syscall.Signal]string{
+	syscall.SIGHUP:  "SIGHUP",
+	syscall.SIGINT:  "SIGINT",
+	syscall.SIGQUIT: "SIGQUIT",
+	syscall.SIGILL:  "SIGILL",
+	syscall.SIGTRAP: "SIGTRAP",
+	syscall.SIGABRT: "SIGABRT",
+	syscall.SIGBUS:  "SIGBUS",
+	syscall.SIGFPE:  "SIGFPE",
+	syscall.SIGKILL: "SIGKILL",
+	syscall.SIGSEGV: "SIGSEGV",
+	syscall.SIGPIPE: "SIGPIPE",
+	syscall.SIGALRM: "SIGALRM",
+	syscall.SIGTERM: "SIGTERM",
+}
+
+// signalName returns the conventional name of a signal
+func signalName(sig syscall.Signal) string {
+	if name, ok := signalNames[sig]; ok {
+		return name
+	}
+	return fmt.Sprintf("SIG%d", int(sig))
+}
+
+// CommandExitError reports that the wrapped command exited unsuccessfully; opentracer exits
+// with the same code rather than printing an error of its own
+type CommandExitError struct {
+	Code   int
+	Signal string
+}
+
+// Error implements error
+func (e *CommandExitError) Error() string {
+	if e.Signal != "" {
+		return fmt.Sprintf("run command terminated by signal %s", e.Signal)
+	}
+	return fmt.Sprintf("run command exited with error: %d", e.Code)
+}
+
+// ExitCode returns the code opentracer should exit with
+func (e *CommandExitError) ExitCode() int {
+	return e.Code
+}
+
+// CommandStartError reports that the wrapped command could not be started; like a shell,
+// opentracer exits 127 when it was not found and 126 when it could not be executed
+type CommandStartError struct {
+	Err error
+}
+
+// Error implements error
+func (e *CommandStartError) Error() string {
+	return fmt.Sprintf("run command could not be started: %s", e.Err)
+}
+
+// Unwrap returns the error from starting the command
+func (e *CommandStartError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the code opentracer should exit with
+func (e *CommandStartError) ExitCode() int {
+	if errors.Is(e.Err, exec.ErrNotFound) || errors.Is(e.Err, fs.ErrNotExist) {
+		return notFoundExitCode
+	}
+	return notExecutableExitCode
+}
+
+// exitStatus returns the shell-style exit code of a finished process and the name of the
+// signal which terminated it (if any)
+func exitStatus(state *os.ProcessState) (int, string) {
+	if ws, ok := state.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		return signalExitCodeBase + int(ws.Signal()), signalName(ws.Signal())
+	}
+	return state.ExitCode(), ""
+}
+
+// exitStatusAttributes describes a finished process as span attributes
+func exitStatusAttributes(code int, signal string) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{processExitCodeKey.Int(code)}
+	if signal != "" {
+		attrs = append(attrs, processExitSignalKey.String(signal))
+	}
+	return attrs
+}
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/davidalpert/go-printers/v1"
+	"github.com/davidalpert/opentracer/internal/otlpjson"
+	collectortracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// readLoggedSpans reads every span from an otlp-json trace log
+func readLoggedSpans(t *testing.T, logFile string) []*tracepb.Span {
+	t.Helper()
+	f, err := os.Open(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	spans := make([]*tracepb.Span, 0)
+	if err := otlpjson.ReadTraceRequests(f, func(req *collectortracepb.ExportTraceServiceRequest) error {
+		for _, rs := range req.ResourceSpans {
+			for _, ils := range rs.InstrumentationLibrarySpans {
+				spans = append(spans, ils.Spans...)
+			}
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return spans
+}
+
+// spanAttribute returns the value of a span attribute formatted as a string
+func spanAttribute(span *tracepb.Span, key string) (string, bool) {
+	for _, kv := range span.Attributes {
+		if kv.Key == key {
+			switch v := kv.Value.Value.(type) {
+			case *commonpb.AnyValue_StringValue:
+				return v.StringValue, true
+			case *commonpb.AnyValue_IntValue:
+				return strconv.FormatInt(v.IntValue, 10), true
+			case *commonpb.AnyValue_BoolValue:
+				return strconv.FormatBool(v.BoolValue), true
+			default:
+				return kv.Value.String(), true
+			}
+		}
+	}
+	return "", false
+}
+
+func TestRunOptions_Run_propagatesExitCode(t *testing.T) {
+	tests := []struct {
+		name       string
+		script     string
+		wantCode   int
+		wantSignal string
+	}{
+		{name: "success", script: "exit 0", wantCode: 0},
+		{name: "exit code", script: "exit 42", wantCode: 42},
+		{name: "signal", script: "kill -TERM $$", wantCode: 143, wantSignal: "SIGTERM"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logFile := filepath.Join(t.TempDir(), "traces.jsonl")
+			cmd := NewCmdRun(printers.DefaultOSStreams())
+			cmd.SetArgs([]string{"--trace-log-file", logFile, "--span-delay", "0s", "sh", "--", "-c", tt.script})
+			err := cmd.Execute()
+
+			gotCode := 0
+			var exitErr *CommandExitError
+			if errors.As(err, &exitErr) {
+				gotCode = exitErr.ExitCode()
+			} else if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if gotCode != tt.wantCode {
+				t.Errorf("exit code = %d, want %d", gotCode, tt.wantCode)
+			}
+
+			span := readLoggedSpans(t, logFile)[0]
+			if got, _ := spanAttribute(span, string(processExitCodeKey)); got != strconv.Itoa(tt.wantCode) {
+				t.Errorf("%s = %s, want %d", processExitCodeKey, got, tt.wantCode)
+			}
+			if got, _ := spanAttribute(span, string(processExitSignalKey)); got != tt.wantSignal {
+				t.Errorf("%s = %q, want %q", processExitSignalKey, got, tt.wantSignal)
+			}
+		})
+	}
+}
+
+func TestRunOptions_Run_commandCannotStart(t *testing.T) {
+	dir := t.TempDir()
+	notExecutable := filepath.Join(dir, "not-executable")
+	if err := os.WriteFile(notExecutable, []byte("#!/bin/sh\nexit 0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		command  string
+		wantCode int
+	}{
+		{name: "not on PATH", command: "opentracer-no-such-command", wantCode: notFoundExitCode},
+		{name: "no such file", command: filepath.Join(dir, "missing"), wantCode: notFoundExitCode},
+		{name: "not executable", command: notExecutable, wantCode: notExecutableExitCode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logFile := filepath.Join(t.TempDir(), "traces.jsonl")
+			s, _, _, errOut := printers.NewTestIOStreams()
+			cmd := NewCmdRun(s)
+			cmd.SetArgs([]string{"--trace-log-file", logFile, "--span-delay", "0s", "--retries", "2", tt.command})
+			err := cmd.Execute()
+
+			var startErr *CommandStartError
+			if !errors.As(err, &startErr) {
+				t.Fatalf("Execute() error = %v, want a CommandStartError", err)
+			}
+			if got := startErr.ExitCode(); got != tt.wantCode {
+				t.Errorf("exit code = %d, want %d", got, tt.wantCode)
+			}
+			if !strings.Contains(errOut.String(), "could not be started") {
+				t.Errorf("stderr = %q, want the start error", errOut.String())
+			}
+
+			spans := readLoggedSpans(t, logFile)
+			if len(spans) != 2 {
+				t.Fatalf("got %d spans, want the run and a single attempt", len(spans))
+			}
+			for _, span := range spans {
+				if got, _ := spanAttribute(span, string(processExitCodeKey)); got != strconv.Itoa(tt.wantCode) {
+					t.Errorf("%s %s = %s, want %d", span.Name, processExitCodeKey, got, tt.wantCode)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// supported --on-export-error policies
+const (
+	onExportErrorIgnore = "ignore"
+	onExportErrorWarn   = "warn"
+	onExportErrorFail   = "fail"
+)
+
+var onExportErrorPolicies = []string{onExportErrorIgnore, onExportErrorWarn, onExportErrorFail}
+
+// exportErrorRecorder is an otel.ErrorHandler which keeps the first error reported by the
+// span processors (e.g. a failed batch export) so that it can be handled per --on-export-error
+type exportErrorRecorder struct {
+	mu  sync.Mutex
+	err error
+}
+
+// Handle implements otel.ErrorHandler
+func (r *exportErrorRecorder) Handle(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+func (r *exportErrorRecorder) firstError() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// shutdownTracerProvider flushes and shuts down tp within --export-timeout (or --signal-grace
+// when the run was cancelled) and returns the first export error seen during the run, if any
+func (o *RunOptions) shutdownTracerProvider(tp *sdktrace.TracerProvider, recorder *exportErrorRecorder) error {
+	timeout := o.shutdownTimeout()
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	if err := tp.Shutdown(ctx); err != nil {
+		return err
+	}
+	return recorder.firstError()
+}
+
+// shutdownTimeout bounds the final flush by --export-timeout, or by --signal-grace when the
+// run was cancelled and that is shorter
+func (o *RunOptions) shutdownTimeout() time.Duration {
+	timeout := o.ExportTimeout
+	if o.cancelledBy != nil && o.SignalGrace > 0 && (timeout <= 0 || o.SignalGrace < timeout) {
+		timeout = o.SignalGrace
+	}
+	return timeout
+}
+
+// handleExportError applies the --on-export-error policy; the wrapped command's own error
+// always takes precedence so a telemetry outage never masks its exit code
+func (o *RunOptions) handleExportError(runErr error, signal string, exportErr error) error {
+	if exportErr == nil || o.OnExportError == onExportErrorIgnore {
+		return runErr
+	}
+
+	fmt.Fprintf(o.ErrOut, "opentracer: failed to export %s: %s\n", signal, exportErr)
+	if o.OnExportError == onExportErrorFail && runErr == nil {
+		return fmt.Errorf("failed to export %s: %w", signal, exportErr)
+	}
+	return runErr
+}
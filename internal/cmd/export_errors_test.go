@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/davidalpert/go-printers/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRunOptions_handleExportError(t *testing.T) {
+	runErr := errors.New("run command exited with error: 3")
+	exportErr := errors.New("connection refused")
+	tests := []struct {
+		policy     string
+		runErr     error
+		exportErr  error
+		wantErr    error
+		wantWarned bool
+	}{
+		{policy: onExportErrorIgnore, exportErr: exportErr},
+		{policy: onExportErrorWarn, exportErr: exportErr, wantWarned: true},
+		{policy: onExportErrorWarn, runErr: runErr, exportErr: exportErr, wantErr: runErr, wantWarned: true},
+		{policy: onExportErrorFail, runErr: runErr, exportErr: exportErr, wantErr: runErr, wantWarned: true},
+		{policy: onExportErrorFail, runErr: runErr, wantErr: runErr},
+		{policy: onExportErrorFail},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			s, _, _, errOut := printers.NewTestIOStreams()
+			o := NewRunOptions(s)
+			o.OnExportError = tt.policy
+			err := o.handleExportError(tt.runErr, "spans", tt.exportErr)
+			if err != tt.wantErr {
+				t.Errorf("handleExportError() error = %v, want %v", err, tt.wantErr)
+			}
+			if warned := errOut.Len() > 0; warned != tt.wantWarned {
+				t.Errorf("handleExportError() warned = %v, want %v", warned, tt.wantWarned)
+			}
+		})
+	}
+
+	t.Run("fail when the command succeeded", func(t *testing.T) {
+		s, _, _, _ := printers.NewTestIOStreams()
+		o := NewRunOptions(s)
+		o.OnExportError = onExportErrorFail
+		if err := o.handleExportError(nil, "spans", exportErr); !errors.Is(err, exportErr) {
+			t.Errorf("handleExportError() error = %v, want it to wrap %v", err, exportErr)
+		}
+	})
+}
+
+func TestRunOptions_Run_exportFailureDoesNotPanic(t *testing.T) {
+	receiver, endpoint := startFakeGrpcTraceReceiver(t)
+	receiver.setErr(status.Error(codes.InvalidArgument, "collector rejected the batch"))
+
+	for _, policy := range onExportErrorPolicies {
+		t.Run(policy, func(t *testing.T) {
+			s, _, _, errOut := printers.NewTestIOStreams()
+			cmd := NewCmdRun(s)
+			cmd.SetArgs([]string{"--trace-grpc-endpoint", endpoint, "--on-export-error", policy, "--export-timeout", "2s", "--span-delay", "0s", "true"})
+			err := cmd.Execute()
+			if (err != nil) != (policy == onExportErrorFail) {
+				t.Errorf("Execute() error = %v", err)
+			}
+			if warned := strings.Contains(errOut.String(), "failed to export spans"); warned != (policy != onExportErrorIgnore) {
+				t.Errorf("stderr = %q", errOut.String())
+			}
+		})
+	}
+}
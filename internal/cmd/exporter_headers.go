@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/davidalpert/opentracer/internal/redact"
+)
+
+// redactedValue replaces secret values (e.g. header values) in debug output
+const redactedValue = redact.Placeholder
+
+// completeTraceHeaders layers headers from --trace-header-file and then --trace-header
+// over any headers already read from the environment so that flags take precedence
+func (o *OTLPExporterOptions) completeTraceHeaders() error {
+	if o.TraceHeaders == nil {
+		o.TraceHeaders = make(map[string]string)
+	}
+
+	if o.TraceHeaderFile != "" {
+		headers, err := readTraceHeaderFile(o.TraceHeaderFile)
+		if err != nil {
+			return err
+		}
+		for k, v := range headers {
+			o.TraceHeaders[k] = v
+		}
+	}
+
+	for _, raw := range o.TraceHeadersRaw {
+		k, v, err := parseTraceHeader(raw)
+		if err != nil {
+			return err
+		}
+		o.TraceHeaders[k] = v
+	}
+
+	return nil
+}
+
+// parseTraceHeader splits a raw key=value header
+func parseTraceHeader(s string) (string, string, error) {
+	parts := strings.SplitN(s, "=", 2)
+	if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+		return "", "", fmt.Errorf("must specify header as key=value: '%s'", s)
+	}
+	return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), nil
+}
+
+// readTraceHeaderFile reads one key=value header per line; blank lines and lines starting with '#' are ignored
+func readTraceHeaderFile(filename string) (map[string]string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	headers := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		k, v, err := parseTraceHeader(line)
+		if err != nil {
+			// do not echo the line back as it may hold a secret
+			return nil, fmt.Errorf("%s:%d: must specify header as key=value", filename, lineNumber)
+		}
+		headers[k] = v
+	}
+	return headers, scanner.Err()
+}
+
+// redactedHeaderLines formats headers for debug output without exposing their values
+func redactedHeaderLines(headers map[string]string) []string {
+	lines := make([]string, 0, len(headers))
+	for k := range headers {
+		lines = append(lines, fmt.Sprintf("%s=%s", k, redactedValue))
+	}
+	sort.Strings(lines)
+	return lines
+}
@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"google.golang.org/grpc/credentials"
+)
+
+// OTLPExporterOptions configures where and how spans are sent to an OTLP endpoint
+type OTLPExporterOptions struct {
+	TraceHeaders            map[string]string
+	TraceHeadersRaw         []string
+	TraceHeaderFile         string
+	TraceCACertFile         string
+	TraceClientCertFile     string
+	TraceClientKeyFile      string
+	TraceInsecureSkipVerify bool
+	TraceOLTPGrpcEndpoint   string
+	TraceOLTPHttpEndpoint   string
+	ExportTimeout           time.Duration
+}
+
+// AddOTLPExporterFlags binds the OTLP exporter flags to the given FlagSet
+func (o *OTLPExporterOptions) AddOTLPExporterFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&o.TraceOLTPHttpEndpoint, "trace-http-endpoint", "", "sent traces over http to this endpoint")
+	flags.StringVar(&o.TraceOLTPGrpcEndpoint, "trace-grpc-endpoint", "", "send traces over grpc to this endpoint")
+	flags.StringArrayVar(&o.TraceHeadersRaw, "trace-header", make([]string, 0), "headers to send with each OTLP export in the format key=value (repeatable)")
+	flags.StringVar(&o.TraceHeaderFile, "trace-header-file", "", "read OTLP export headers from this file, one key=value per line (keeps secrets out of argv)")
+	flags.StringVar(&o.TraceCACertFile, "trace-ca-cert", "", "verify the OTLP endpoint with this PEM-encoded CA certificate")
+	flags.StringVar(&o.TraceClientCertFile, "trace-client-cert", "", "present this PEM-encoded client certificate to the OTLP endpoint (mutual TLS)")
+	flags.StringVar(&o.TraceClientKeyFile, "trace-client-key", "", "PEM-encoded private key for --trace-client-cert")
+	flags.BoolVar(&o.TraceInsecureSkipVerify, "trace-insecure-skip-verify", false, "skip verification of the OTLP endpoint's TLS certificate")
+	flags.DurationVar(&o.ExportTimeout, "export-timeout", 10*time.Second, "how long to wait for each OTLP export, including retries, and for the final flush (golang time.Duration)")
+}
+
+// Complete completes the OTLPExporterOptions from the environment and header flags; the
+// OTEL_EXPORTER_OTLP_* endpoint is only overridden by the --trace-*-endpoint flags
+func (o *OTLPExporterOptions) Complete() error {
+	if err := o.completeFromEnvironment(); err != nil {
+		return err
+	}
+	return o.completeTraceHeaders()
+}
+
+// Validate validates the OTLPExporterOptions
+func (o *OTLPExporterOptions) Validate() error {
+	return o.validateTLS()
+}
+
+// hasEndpoint reports whether an OTLP endpoint has been configured
+func (o *OTLPExporterOptions) hasEndpoint() bool {
+	return o.TraceOLTPHttpEndpoint != "" || o.TraceOLTPGrpcEndpoint != ""
+}
+
+// traceClient is an otlptrace.Client named by the protocol of its endpoint
+type traceClient struct {
+	otlptrace.Client
+	Protocol string
+}
+
+// newTraceClients creates an unstarted otlptrace.Client for each configured endpoint; each
+// export, including its retries, is bounded by exportTimeout (normally --export-timeout)
+func (o *OTLPExporterOptions) newTraceClients(exportTimeout time.Duration) ([]traceClient, error) {
+	tlsCfg, err := o.newTraceTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	clients := make([]traceClient, 0)
+	if o.TraceOLTPHttpEndpoint != "" {
+		opts := buildHttpTraceExporterSpanOptionsForEndpoint(o.TraceOLTPHttpEndpoint, o.TraceHeaders, tlsCfg)
+		if exportTimeout > 0 {
+			// the http timeout applies to each attempt so also bound the retries
+			opts = append(opts,
+				otlptracehttp.WithTimeout(exportTimeout),
+				otlptracehttp.WithRetry(otlptracehttp.RetryConfig{Enabled: true, InitialInterval: 500 * time.Millisecond, MaxInterval: exportTimeout / 2, MaxElapsedTime: exportTimeout}),
+			)
+		}
+		clients = append(clients, traceClient{Client: otlptracehttp.NewClient(opts...), Protocol: "http"})
+	}
+	if o.TraceOLTPGrpcEndpoint != "" {
+		opts := buildGrpcTraceExporterSpanOptionsForEndpoint(o.TraceOLTPGrpcEndpoint, o.TraceHeaders, tlsCfg)
+		if exportTimeout > 0 {
+			// the grpc timeout already bounds the export including its retries
+			opts = append(opts, otlptracegrpc.WithTimeout(exportTimeout))
+		}
+		clients = append(clients, traceClient{Client: otlptracegrpc.NewClient(opts...), Protocol: "grpc"})
+	}
+	return clients, nil
+}
+
+// printDebugExporters prints the configured endpoints and (redacted) headers
+func (o *OTLPExporterOptions) printDebugExporters() {
+	if o.TraceOLTPHttpEndpoint != "" {
+		fmt.Printf("exporting to trace http endpoint: %s\n", o.TraceOLTPHttpEndpoint)
+	}
+	if o.TraceOLTPGrpcEndpoint != "" {
+		fmt.Printf("exporting to trace grpc endpoint: %s\n", o.TraceOLTPGrpcEndpoint)
+	}
+	for _, h := range redactedHeaderLines(o.TraceHeaders) {
+		fmt.Printf("with trace header: %s\n", h)
+	}
+}
+
+func buildHttpTraceExporterSpanOptionsForEndpoint(endpoint string, headers map[string]string, tlsCfg *tls.Config) []otlptracehttp.Option {
+	opts := []otlptracehttp.Option{}
+
+	if u, err := url.Parse(endpoint); err == nil && u.Scheme != "" && u.Host != "" {
+		// a full URL (e.g. from OTEL_EXPORTER_OTLP_ENDPOINT) carries the host, path, and scheme separately
+		opts = append(opts, otlptracehttp.WithEndpoint(u.Host))
+		if u.Path != "" && u.Path != "/" {
+			opts = append(opts, otlptracehttp.WithURLPath(u.Path))
+		}
+	} else {
+		opts = append(opts, otlptracehttp.WithEndpoint(endpoint))
+	}
+
+	if tlsCfg != nil {
+		opts = append(opts, otlptracehttp.WithTLSClientConfig(tlsCfg))
+	} else if !strings.HasPrefix(endpoint, "https://") {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+
+	if len(headers) > 0 {
+		opts = append(opts, otlptracehttp.WithHeaders(headers))
+	}
+
+	return opts
+}
+
+func buildGrpcTraceExporterSpanOptionsForEndpoint(endpoint string, headers map[string]string, tlsCfg *tls.Config) []otlptracegrpc.Option {
+	// the grpc exporter expects a bare host:port so strip any scheme we use to detect TLS
+	opts := []otlptracegrpc.Option{
+		otlptracegrpc.WithEndpoint(strings.TrimPrefix(strings.TrimPrefix(endpoint, "https://"), "http://")),
+	}
+
+	if tlsCfg != nil {
+		opts = append(opts, otlptracegrpc.WithTLSCredentials(credentials.NewTLS(tlsCfg)))
+	} else if !strings.HasPrefix(endpoint, "https://") {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+
+	if len(headers) > 0 {
+		opts = append(opts, otlptracegrpc.WithHeaders(headers))
+	}
+
+	return opts
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// usesCustomTLS reports whether any of the --trace-* TLS flags were set
+func (o *OTLPExporterOptions) usesCustomTLS() bool {
+	return o.TraceCACertFile != "" || o.TraceClientCertFile != "" || o.TraceClientKeyFile != "" || o.TraceInsecureSkipVerify
+}
+
+// validateTLS validates the --trace-* TLS flags
+func (o *OTLPExporterOptions) validateTLS() error {
+	if (o.TraceClientCertFile == "") != (o.TraceClientKeyFile == "") {
+		return fmt.Errorf("--trace-client-cert and --trace-client-key must be set together")
+	}
+	return nil
+}
+
+// newTraceTLSConfig builds a tls.Config for the OTLP exporters from the --trace-* TLS flags;
+// it returns nil when none are set so the exporters keep their default behavior
+func (o *OTLPExporterOptions) newTraceTLSConfig() (*tls.Config, error) {
+	if !o.usesCustomTLS() {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: o.TraceInsecureSkipVerify,
+	}
+
+	if o.TraceCACertFile != "" {
+		pem, err := os.ReadFile(o.TraceCACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading --trace-ca-cert: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificates found in --trace-ca-cert '%s'", o.TraceCACertFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if o.TraceClientCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(o.TraceClientCertFile, o.TraceClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading --trace-client-cert and --trace-client-key: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
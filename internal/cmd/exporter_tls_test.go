@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// testCertificate is a generated certificate with its PEM encodings written to disk
+type testCertificate struct {
+	cert     *x509.Certificate
+	key      *ecdsa.PrivateKey
+	tls      tls.Certificate
+	certFile string
+	keyFile  string
+}
+
+func newTestCertificate(t *testing.T, dir string, name string, template *x509.Certificate, parent *testCertificate) *testCertificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template.Subject = pkix.Name{CommonName: name}
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+
+	signerCert, signerKey := template, key
+	if parent != nil {
+		signerCert, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signerCert, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &testCertificate{
+		cert:     cert,
+		key:      key,
+		tls:      tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key},
+		certFile: filepath.Join(dir, name+".crt"),
+		keyFile:  filepath.Join(dir, name+".key"),
+	}
+	if err := os.WriteFile(c.certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(c.keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// exportOneSpan synchronously sends a single span through an OTLP/HTTP exporter configured from o
+func exportOneSpan(o *OTLPExporterOptions) error {
+	tlsCfg, err := o.newTraceTLSConfig()
+	if err != nil {
+		return err
+	}
+	exp, err := otlptracehttp.New(context.Background(),
+		append(buildHttpTraceExporterSpanOptionsForEndpoint(o.TraceOLTPHttpEndpoint, o.TraceHeaders, tlsCfg), otlptracehttp.WithRetry(otlptracehttp.RetryConfig{Enabled: false}))...,
+	)
+	if err != nil {
+		return err
+	}
+	defer exp.Shutdown(context.Background())
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	_, span := tp.Tracer("test").Start(context.Background(), "TLSRun")
+	span.End()
+	return exp.ExportSpans(context.Background(), recorder.Ended())
+}
+
+func TestOTLPExporterOptions_newTraceTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCertificate(t, dir, "ca", &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}, nil)
+	server := newTestCertificate(t, dir, "server", &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca)
+	client := newTestCertificate(t, dir, "client", &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca)
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca.cert)
+
+	var received int32
+	// one server per client auth policy; a running server's tls.Config must not be changed
+	newServer := func(clientAuth tls.ClientAuthType) *httptest.Server {
+		ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&received, 1)
+			w.WriteHeader(http.StatusOK)
+		}))
+		ts.TLS = &tls.Config{
+			Certificates: []tls.Certificate{server.tls},
+			ClientCAs:    clientCAs,
+			ClientAuth:   clientAuth,
+		}
+		ts.StartTLS()
+		return ts
+	}
+	tlsServer := newServer(tls.VerifyClientCertIfGiven)
+	defer tlsServer.Close()
+	mTLSServer := newServer(tls.RequireAndVerifyClientCert)
+	defer mTLSServer.Close()
+
+	tests := []struct {
+		name    string
+		options OTLPExporterOptions
+		mTLS    bool
+		wantErr bool
+	}{
+		{
+			name:    "private CA",
+			options: OTLPExporterOptions{TraceCACertFile: ca.certFile},
+		},
+		{
+			name:    "unknown CA",
+			options: OTLPExporterOptions{TraceCACertFile: client.certFile},
+			wantErr: true,
+		},
+		{
+			name:    "insecure skip verify",
+			options: OTLPExporterOptions{TraceInsecureSkipVerify: true},
+		},
+		{
+			name:    "mutual TLS",
+			options: OTLPExporterOptions{TraceCACertFile: ca.certFile, TraceClientCertFile: client.certFile, TraceClientKeyFile: client.keyFile},
+			mTLS:    true,
+		},
+		{
+			name:    "mutual TLS without client certificate",
+			options: OTLPExporterOptions{TraceCACertFile: ca.certFile},
+			mTLS:    true,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := tlsServer
+			if tt.mTLS {
+				ts = mTLSServer
+			}
+			before := atomic.LoadInt32(&received)
+
+			o := tt.options
+			o.TraceOLTPHttpEndpoint = ts.URL + "/v1/traces"
+			err := exportOneSpan(&o)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("export error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := atomic.LoadInt32(&received) - before; !tt.wantErr && got != 1 {
+				t.Errorf("server received %d exports, want 1", got)
+			}
+		})
+	}
+}
+
+func TestOTLPExporterOptions_validateTLS(t *testing.T) {
+	o := &OTLPExporterOptions{TraceClientCertFile: "client.crt"}
+	if err := o.validateTLS(); err == nil {
+		t.Errorf("validateTLS() expected an error when --trace-client-key is missing")
+	}
+}
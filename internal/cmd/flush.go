@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/davidalpert/go-printers/v1"
+	"github.com/spf13/cobra"
+)
+
+// FlushOptions is a struct to support the flush command
+type FlushOptions struct {
+	*printers.PrinterOptions
+	OTLPExporterOptions
+	SpoolOptions
+	Debug bool
+}
+
+// NewFlushOptions returns initialized FlushOptions
+func NewFlushOptions(s printers.IOStreams) *FlushOptions {
+	return &FlushOptions{
+		PrinterOptions: printers.NewPrinterOptions().WithStreams(s).WithDefaultOutput("text"),
+	}
+}
+
+// NewCmdFlush creates the flush command
+func NewCmdFlush(s printers.IOStreams) *cobra.Command {
+	o := NewFlushOptions(s)
+	var cmd = &cobra.Command{
+		Use:   "flush",
+		Short: "Resend the batches in a spool directory to an OTLP endpoint",
+		Long: `Resend the batches which 'run --spool-dir' could not export
+
+opentracer flush --spool-dir /var/spool/opentracer --trace-http-endpoint $OTELCOL_OTLP_HTTP_ENDPOINT
+
+Batches are sent oldest first; flushing an endpoint stops at its first failed send and leaves
+the remaining batches for next time. Batches which have failed --spool-max-attempts times or are older than
+--spool-max-age are dropped. Each endpoint spools to its own subdirectory (http or grpc) and
+only gets its own batches back. 'run' also flushes its --spool-dir in the background while the
+command runs.
+`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(cmd, args); err != nil {
+				return err
+			}
+			if err := o.Validate(); err != nil {
+				return err
+			}
+			if err := o.Run(); err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+
+	o.AddPrinterFlags(cmd.Flags())
+	o.AddOTLPExporterFlags(cmd.Flags())
+	o.AddSpoolFlags(cmd.Flags())
+	cmd.Flags().BoolVar(&o.Debug, "debug", false, "debug")
+	return cmd
+}
+
+// Complete completes the FlushOptions
+func (o *FlushOptions) Complete(cmd *cobra.Command, args []string) error {
+	return o.OTLPExporterOptions.Complete()
+}
+
+// Validate validates the FlushOptions
+func (o *FlushOptions) Validate() error {
+	if o.SpoolDir == "" {
+		return fmt.Errorf("--spool-dir is required")
+	}
+	if !o.hasEndpoint() {
+		return fmt.Errorf("at least one of --trace-http-endpoint, --trace-grpc-endpoint, or %s must be set", envOTLPEndpoint)
+	}
+	if err := o.SpoolOptions.Validate(); err != nil {
+		return err
+	}
+	if err := o.OTLPExporterOptions.Validate(); err != nil {
+		return err
+	}
+	return o.PrinterOptions.Validate()
+}
+
+// Run executes the command
+func (o *FlushOptions) Run() error {
+	if o.Debug {
+		o.printDebugExporters()
+	}
+
+	clients, err := o.newTraceClients(o.ExportTimeout)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	for _, client := range clients {
+		if err := client.Start(ctx); err != nil {
+			return err
+		}
+		defer client.Stop(ctx)
+	}
+
+	result, flushErr := o.flushSpools(ctx, clients)
+	if err := o.WriteOutput(result); err != nil {
+		return err
+	}
+	if flushErr != nil {
+		return fmt.Errorf("flush stopped early: %w", flushErr)
+	}
+	return nil
+}
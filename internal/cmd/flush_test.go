@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/davidalpert/go-printers/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestFlushOptions_Run_resendsSpooledBatches(t *testing.T) {
+	spoolDir := t.TempDir()
+	receiver, endpoint := startFakeGrpcTraceReceiver(t)
+	receiver.setErr(status.Error(codes.InvalidArgument, "collector rejected the batch"))
+
+	cmd := NewCmdRun(printers.DefaultOSStreams())
+	cmd.SetArgs([]string{"--trace-grpc-endpoint", endpoint, "--spool-dir", spoolDir, "--span-name", "Spooled", "--span-delay", "0s", "true"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("run Execute() error = %v", err)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(spoolDir, "grpc")); len(entries) != 1 {
+		t.Fatalf("grpc spool has %d entries, want 1", len(entries))
+	}
+
+	receiver.setErr(nil)
+	s, _, out, _ := printers.NewTestIOStreams()
+	cmd = NewCmdFlush(s)
+	cmd.SetArgs([]string{"--trace-grpc-endpoint", endpoint, "--spool-dir", spoolDir})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("flush Execute() error = %v", err)
+	}
+
+	if got, want := out.String(), "sent 1, retained 0, expired 0, and dropped 0 spooled batches\n"; got != want {
+		t.Errorf("flush output = %q, want %q", got, want)
+	}
+	if got := receiver.spanNames(); !reflect.DeepEqual(got, []string{"Spooled"}) {
+		t.Errorf("receiver got spans %v, want [Spooled]", got)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(spoolDir, "grpc")); len(entries) != 0 {
+		t.Errorf("grpc spool has %d entries after flush, want 0", len(entries))
+	}
+}
+
+func TestRunOptions_Run_spoolsBatchesForAnUnreachableEndpoint(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	// nothing listens here any more so every export attempt fails and is retried
+	refused := l.Addr().String()
+	l.Close()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// hold the request well past --export-timeout
+		select {
+		case <-r.Context().Done():
+		case <-time.After(3 * time.Second):
+		}
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		args     []string
+		protocol string
+	}{
+		{name: "grpc connection refused", args: []string{"--trace-grpc-endpoint", refused}, protocol: "grpc"},
+		{name: "http never answers", args: []string{"--trace-http-endpoint", ts.URL + "/v1/traces"}, protocol: "http"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spoolDir := t.TempDir()
+			start := time.Now()
+			cmd := NewCmdRun(printers.DefaultOSStreams())
+			args := append(tt.args, "--spool-dir", spoolDir, "--export-timeout", "1s", "--span-delay", "0s", "true")
+			cmd.SetArgs(args)
+			if err := cmd.Execute(); err != nil {
+				t.Fatalf("run Execute() error = %v", err)
+			}
+
+			if entries, _ := os.ReadDir(filepath.Join(spoolDir, tt.protocol)); len(entries) != 1 {
+				t.Errorf("%s spool has %d entries, want 1", tt.protocol, len(entries))
+			}
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("run took %s, want it bounded by --export-timeout", elapsed)
+			}
+		})
+	}
+}
+
+func TestRunOptions_Run_resendsSpooledBatchesOnlyToTheirEndpoint(t *testing.T) {
+	spoolDir := t.TempDir()
+	receiver, grpcEndpoint := startFakeGrpcTraceReceiver(t)
+	receiver.setErr(status.Error(codes.InvalidArgument, "collector rejected the batch"))
+
+	var httpRequests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&httpRequests, 1)
+	}))
+	defer ts.Close()
+
+	run := func(spanName string) {
+		t.Helper()
+		cmd := NewCmdRun(printers.DefaultOSStreams())
+		cmd.SetArgs([]string{"--trace-grpc-endpoint", grpcEndpoint, "--trace-http-endpoint", ts.URL + "/v1/traces",
+			"--spool-dir", spoolDir, "--span-name", spanName, "--span-delay", "0s", "true"})
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("run Execute() error = %v", err)
+		}
+	}
+
+	run("First")
+	if entries, _ := os.ReadDir(filepath.Join(spoolDir, "http")); len(entries) != 0 {
+		t.Fatalf("http spool has %d entries, want 0", len(entries))
+	}
+
+	receiver.setErr(nil)
+	run("Second")
+
+	got := receiver.spanNames()
+	sort.Strings(got)
+	if want := []string{"First", "Second"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("grpc receiver got spans %v, want %v", got, want)
+	}
+	if got := atomic.LoadInt32(&httpRequests); got != 2 {
+		t.Errorf("http receiver got %d requests, want 2 (one per run, no resends)", got)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(spoolDir, "grpc")); len(entries) != 0 {
+		t.Errorf("grpc spool has %d entries after the second run, want 0", len(entries))
+	}
+}
+
+func TestFlushOptions_Validate(t *testing.T) {
+	o := NewFlushOptions(printers.DefaultOSStreams())
+	o.TraceOLTPGrpcEndpoint = "localhost:4317"
+	if err := o.Validate(); err == nil {
+		t.Errorf("Validate() expected an error without --spool-dir")
+	}
+}
@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/davidalpert/opentracer/internal/otlplogs"
+	"github.com/davidalpert/opentracer/internal/otlpproto"
+	"github.com/davidalpert/opentracer/internal/redact"
+	"github.com/spf13/pflag"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+// logIOStreamKey names the stream a log record was read from
+const logIOStreamKey = attribute.Key("log.iostream")
+
+// maxLogLineBytes splits lines longer than this into several records
+const maxLogLineBytes = 64 * 1024
+
+// logSeverities maps the supported severity names to OTLP severity numbers
+var logSeverities = map[string]logspb.SeverityNumber{
+	"TRACE": logspb.SeverityNumber_SEVERITY_NUMBER_TRACE,
+	"DEBUG": logspb.SeverityNumber_SEVERITY_NUMBER_DEBUG,
+	"INFO":  logspb.SeverityNumber_SEVERITY_NUMBER_INFO,
+	"WARN":  logspb.SeverityNumber_SEVERITY_NUMBER_WARN,
+	"ERROR": logspb.SeverityNumber_SEVERITY_NUMBER_ERROR,
+	"FATAL": logspb.SeverityNumber_SEVERITY_NUMBER_FATAL,
+}
+
+var logSeverityNames = []string{"TRACE", "DEBUG", "INFO", "WARN", "ERROR", "FATAL"}
+
+// LogsOptions configures exporting each line the command prints as an OTLP log record
+type LogsOptions struct {
+	LogsEndpoint       string
+	LogsStdoutSeverity string
+	LogsStderrSeverity string
+	LogsErrorPattern   string
+	logsErrorRegexp    *regexp.Regexp
+	logs               *otlplogs.Exporter
+}
+
+// AddLogsFlags binds the logs flags to the given FlagSet
+func (o *LogsOptions) AddLogsFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&o.LogsEndpoint, "logs-endpoint", "", fmt.Sprintf("export each line the command prints as a log record to this OTLP/HTTP endpoint (host:port or URL; the path defaults to %s)", otlplogs.DefaultLogsPath))
+	flags.StringVar(&o.LogsStdoutSeverity, "logs-stdout-severity", "INFO", fmt.Sprintf("severity of stdout log records; one of: %s", strings.Join(logSeverityNames, ", ")))
+	flags.StringVar(&o.LogsStderrSeverity, "logs-stderr-severity", "WARN", fmt.Sprintf("severity of stderr log records; one of: %s", strings.Join(logSeverityNames, ", ")))
+	flags.StringVar(&o.LogsErrorPattern, "logs-error-pattern", "", "log lines matching this regular expression are exported with ERROR severity")
+}
+
+// Validate validates the LogsOptions
+func (o *LogsOptions) Validate() error {
+	if _, ok := logSeverities[o.LogsStdoutSeverity]; !ok {
+		return fmt.Errorf("--logs-stdout-severity must be one of: %s", strings.Join(logSeverityNames, ", "))
+	}
+	if _, ok := logSeverities[o.LogsStderrSeverity]; !ok {
+		return fmt.Errorf("--logs-stderr-severity must be one of: %s", strings.Join(logSeverityNames, ", "))
+	}
+	if o.LogsErrorPattern != "" {
+		re, err := regexp.Compile(o.LogsErrorPattern)
+		if err != nil {
+			return fmt.Errorf("--logs-error-pattern is not a valid regular expression: %w", err)
+		}
+		o.logsErrorRegexp = re
+	}
+	return nil
+}
+
+// newLogsExporter creates the exporter for --logs-endpoint; it shares the resource, headers
+// and TLS settings used for spans
+func (o *RunOptions) newLogsExporter() (*otlplogs.Exporter, error) {
+	tlsCfg, err := o.newTraceTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	client, err := otlplogs.NewHTTPClient(o.LogsEndpoint, o.TraceHeaders, tlsCfg, o.ExportTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return otlplogs.NewExporter(client, o.newTracerResource(), o.VersionDetail.AppName, o.VersionDetail.Version), nil
+}
+
+// shutdownLogs uploads any queued log records within timeout and returns the first export
+// error seen during the run, if any
+func (o *LogsOptions) shutdownLogs(timeout time.Duration) error {
+	if o.logs == nil {
+		return nil
+	}
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return o.logs.Shutdown(ctx)
+}
+
+// outputLogs holds the writers exporting one command's output as log records
+type outputLogs []*logLineWriter
+
+// exportOutputLogs tees stdout and stderr of c (which must not be started yet) into log
+// records correlated with span and redacted by r; it returns nil when --logs-endpoint is not set
+func (o *LogsOptions) exportOutputLogs(c *exec.Cmd, span trace.Span, r *redact.Redactor) outputLogs {
+	if o.logs == nil {
+		return nil
+	}
+	stdout := o.newLogLineWriter(span, r, "stdout", logSeverities[o.LogsStdoutSeverity])
+	stderr := o.newLogLineWriter(span, r, "stderr", logSeverities[o.LogsStderrSeverity])
+	c.Stdout = io.MultiWriter(c.Stdout, stdout)
+	c.Stderr = io.MultiWriter(c.Stderr, stderr)
+	c.WaitDelay = captureWaitDelay
+	return outputLogs{stdout, stderr}
+}
+
+// close emits any unterminated last lines
+func (l outputLogs) close() {
+	for _, w := range l {
+		w.close()
+	}
+}
+
+// logLineWriter emits a log record for each line written to it
+type logLineWriter struct {
+	mu       sync.Mutex
+	o        *LogsOptions
+	redactor *redact.Redactor
+	spanCtx  trace.SpanContext
+	stream   string
+	severity logspb.SeverityNumber
+	partial  []byte
+}
+
+func (o *LogsOptions) newLogLineWriter(span trace.Span, r *redact.Redactor, stream string, severity logspb.SeverityNumber) *logLineWriter {
+	return &logLineWriter{o: o, redactor: r, spanCtx: span.SpanContext(), stream: stream, severity: severity}
+}
+
+// Write implements io.Writer
+func (w *logLineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	n := len(p)
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			w.partial = append(w.partial, p...)
+			for len(w.partial) >= maxLogLineBytes {
+				w.emit(string(w.partial[:maxLogLineBytes]))
+				w.partial = w.partial[maxLogLineBytes:]
+			}
+			break
+		}
+		w.partial = append(w.partial, p[:i]...)
+		w.emit(strings.TrimSuffix(string(w.partial), "\r"))
+		w.partial = w.partial[:0]
+		p = p[i+1:]
+	}
+	return n, nil
+}
+
+func (w *logLineWriter) close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.partial) > 0 {
+		w.emit(string(w.partial))
+		w.partial = nil
+	}
+}
+
+// emit exports one line; it must be called with w.mu held
+func (w *logLineWriter) emit(line string) {
+	severity := w.severity
+	if w.o.logsErrorRegexp != nil && w.o.logsErrorRegexp.MatchString(line) {
+		severity = logspb.SeverityNumber_SEVERITY_NUMBER_ERROR
+	}
+	traceID := w.spanCtx.TraceID()
+	spanID := w.spanCtx.SpanID()
+	w.o.logs.Emit(&logspb.LogRecord{
+		TimeUnixNano:   uint64(time.Now().UnixNano()),
+		SeverityNumber: severity,
+		SeverityText:   severityText(severity),
+		Body:           otlpproto.StringValue(w.redactor.String(line)),
+		Attributes:     otlpproto.KeyValuesToProto([]attribute.KeyValue{logIOStreamKey.String(w.stream)}),
+		TraceId:        traceID[:],
+		SpanId:         spanID[:],
+		Flags:          uint32(w.spanCtx.TraceFlags()),
+	})
+}
+
+// severityText names a severity number as accepted by the --logs-*-severity flags
+func severityText(severity logspb.SeverityNumber) string {
+	for name, number := range logSeverities {
+		if number == severity {
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/davidalpert/go-printers/v1"
+	collectorlogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestRunOptions_Run_logsEndpoint(t *testing.T) {
+	var mu sync.Mutex
+	records := make([]*logspb.LogRecord, 0)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req collectorlogspb.ExportLogsServiceRequest
+		if err := proto.Unmarshal(body, &req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, rl := range req.ResourceLogs {
+			for _, ill := range rl.InstrumentationLibraryLogs {
+				records = append(records, ill.LogRecords...)
+			}
+		}
+	}))
+	defer ts.Close()
+
+	logFile := filepath.Join(t.TempDir(), "traces.jsonl")
+	cmd := NewCmdRun(printers.DefaultOSStreams())
+	cmd.SetArgs([]string{"--trace-log-file", logFile, "--span-delay", "0s", "--logs-endpoint", ts.URL, "--logs-error-pattern", "^ERROR",
+		"sh", "--", "-c", "echo hello; echo careful >&2; echo ERROR failed >&2; printf partial"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	span := readLoggedSpans(t, logFile)[0]
+	want := map[string]logspb.SeverityNumber{
+		"hello":        logspb.SeverityNumber_SEVERITY_NUMBER_INFO,
+		"careful":      logspb.SeverityNumber_SEVERITY_NUMBER_WARN,
+		"ERROR failed": logspb.SeverityNumber_SEVERITY_NUMBER_ERROR,
+		"partial":      logspb.SeverityNumber_SEVERITY_NUMBER_INFO,
+	}
+	if len(records) != len(want) {
+		t.Fatalf("received %d log records, want %d", len(records), len(want))
+	}
+	for _, r := range records {
+		body := r.Body.GetStringValue()
+		if severity, ok := want[body]; !ok || r.SeverityNumber != severity {
+			t.Errorf("record %q has severity %v, want %v", body, r.SeverityNumber, severity)
+		}
+		if hex.EncodeToString(r.TraceId) != hex.EncodeToString(span.TraceId) || hex.EncodeToString(r.SpanId) != hex.EncodeToString(span.SpanId) {
+			t.Errorf("record %q is not correlated with the span", body)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/davidalpert/opentracer/internal/otlpmetrics"
+	"github.com/spf13/pflag"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+)
+
+// names of the metrics recorded for each run
+const (
+	runDurationMetric = "opentracer.run.duration"
+	runCountMetric    = "opentracer.run.count"
+)
+
+// metricsFileStdout makes --metrics-file write to stdout
+const metricsFileStdout = "-"
+
+// runDurationBounds are the histogram bucket bounds for opentracer.run.duration, in seconds
+var runDurationBounds = []float64{0.1, 0.5, 1, 5, 10, 30, 60, 300, 600, 1800, 3600}
+
+// spanNameKey labels run metrics with the --span-name of the run
+const spanNameKey = attribute.Key("span.name")
+
+// MetricsOptions configures exporting run duration and count metrics
+type MetricsOptions struct {
+	Metrics     bool
+	MetricsFile string
+}
+
+// AddMetricsFlags binds the metrics flags to the given FlagSet
+func (o *MetricsOptions) AddMetricsFlags(flags *pflag.FlagSet) {
+	flags.BoolVar(&o.Metrics, "metrics", false, fmt.Sprintf("also export %s and %s metrics to the OTLP trace endpoints", runDurationMetric, runCountMetric))
+	flags.StringVar(&o.MetricsFile, "metrics-file", "", fmt.Sprintf("append %s and %s metrics as OTLP/JSON to this file ('%s' for stdout)", runDurationMetric, runCountMetric, metricsFileStdout))
+}
+
+// newMetricsClients returns a client for --metrics-file and, with --metrics, one for each
+// configured OTLP endpoint; the returned func closes any file opened
+func (o *RunOptions) newMetricsClients() ([]otlpmetrics.Client, func(), error) {
+	cleanupFN := func() {}
+	clients := make([]otlpmetrics.Client, 0)
+	if o.MetricsFile == metricsFileStdout {
+		clients = append(clients, otlpmetrics.NewJSONClient(o.Out))
+	} else if o.MetricsFile != "" {
+		f, err := os.OpenFile(o.MetricsFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, cleanupFN, err
+		}
+		cleanupFN = func() { f.Close() }
+		clients = append(clients, otlpmetrics.NewJSONClient(f))
+	}
+	if !o.Metrics {
+		return clients, cleanupFN, nil
+	}
+
+	tlsCfg, err := o.newTraceTLSConfig()
+	if err != nil {
+		return nil, cleanupFN, err
+	}
+	if o.TraceOLTPHttpEndpoint != "" {
+		// metrics go to the same collector as spans, at its metrics path
+		c, err := otlpmetrics.NewHTTPClient(metricsEndpointForTraceEndpoint(o.TraceOLTPHttpEndpoint), o.TraceHeaders, tlsCfg, o.ExportTimeout)
+		if err != nil {
+			return nil, cleanupFN, err
+		}
+		clients = append(clients, c)
+	}
+	if o.TraceOLTPGrpcEndpoint != "" {
+		clients = append(clients, otlpmetrics.NewGRPCClient(o.TraceOLTPGrpcEndpoint, o.TraceHeaders, tlsCfg, o.ExportTimeout))
+	}
+	return clients, cleanupFN, nil
+}
+
+// exportRunMetrics records a run which started at start and ended at end with runErr and
+// returns the first error from exporting it, if any
+func (o *RunOptions) exportRunMetrics(start, end time.Time, runErr error) error {
+	if !o.Metrics && o.MetricsFile == "" {
+		return nil
+	}
+	clients, cleanupFN, err := o.newMetricsClients()
+	defer cleanupFN()
+	if err != nil {
+		return err
+	}
+
+	code, ok := exitCodeOf(runErr)
+	if !ok {
+		// the command never ran; opentracer itself exits 1
+		code = 1
+	}
+	attrs := []attribute.KeyValue{
+		spanNameKey.String(o.SpanName),
+		semconv.ServiceNameKey.String(o.ServiceName),
+		semconv.DeploymentEnvironmentKey.String(o.DeploymentEnvironment),
+		processExitCodeKey.Int(code),
+	}
+	resourceMetrics := otlpmetrics.NewResourceMetrics(o.newTracerResource(), o.VersionDetail.AppName, o.VersionDetail.Version,
+		otlpmetrics.DeltaHistogram(runDurationMetric, "How long the wrapped command ran", "s", runDurationBounds, end.Sub(start).Seconds(), start, end, attrs),
+		otlpmetrics.DeltaCounter(runCountMetric, "How many times a command was run", "{run}", 1, start, end, attrs),
+	)
+
+	ctx := context.Background()
+	if timeout := o.shutdownTimeout(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	var firstErr error
+	for _, c := range clients {
+		if err := c.UploadMetrics(ctx, resourceMetrics); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// metricsEndpointForTraceEndpoint replaces the path of an OTLP/HTTP trace endpoint with the
+// metrics path; a bare host:port gets the metrics path by default
+func metricsEndpointForTraceEndpoint(endpoint string) string {
+	if !strings.Contains(endpoint, "://") {
+		if i := strings.Index(endpoint, "/"); i >= 0 {
+			return endpoint[:i]
+		}
+		return endpoint
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		// leave it to the client to report the invalid endpoint
+		return endpoint
+	}
+	u.Path = otlpmetrics.DefaultMetricsPath
+	u.RawPath = ""
+	return u.String()
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/davidalpert/go-printers/v1"
+	"github.com/davidalpert/opentracer/internal/otlpjson"
+	"github.com/davidalpert/opentracer/internal/otlpmetrics"
+	collectormetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+)
+
+func TestRunOptions_Run_metricsFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		script   string
+		wantCode int
+	}{
+		{name: "success", script: "exit 0", wantCode: 0},
+		{name: "failure", script: "exit 3", wantCode: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			metricsFile := filepath.Join(dir, "metrics.jsonl")
+			cmd := NewCmdRun(printers.DefaultOSStreams())
+			cmd.SetArgs([]string{"--trace-log-file", filepath.Join(dir, "traces.jsonl"), "--span-delay", "0s", "--span-name", "backup", "--metrics-file", metricsFile, "sh", "--", "-c", tt.script})
+			if _, ok := exitCodeOf(cmd.Execute()); !ok {
+				t.Fatal("Execute() did not run the command")
+			}
+
+			b, err := os.ReadFile(metricsFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var req collectormetricspb.ExportMetricsServiceRequest
+			if err := otlpjson.Unmarshal(bytes.TrimSpace(b), &req); err != nil {
+				t.Fatal(err)
+			}
+			got := map[string]*metricspb.Metric{}
+			for _, m := range req.ResourceMetrics[0].InstrumentationLibraryMetrics[0].Metrics {
+				got[m.Name] = m
+			}
+			duration, count := got[runDurationMetric], got[runCountMetric]
+			if duration == nil || count == nil {
+				t.Fatalf("metrics = %v, want %s and %s", got, runDurationMetric, runCountMetric)
+			}
+			if n := count.GetSum().DataPoints[0].GetAsInt(); n != 1 {
+				t.Errorf("%s = %d, want 1", runCountMetric, n)
+			}
+			labels := map[string]string{}
+			for _, kv := range duration.GetHistogram().DataPoints[0].Attributes {
+				labels[kv.Key] = kv.Value.GetStringValue()
+				if kv.Key == string(processExitCodeKey) {
+					labels[kv.Key] = strconv.FormatInt(kv.Value.GetIntValue(), 10)
+				}
+			}
+			want := map[string]string{"span.name": "backup", "service.name": "opentracer", "deployment.environment": "prd", string(processExitCodeKey): strconv.Itoa(tt.wantCode)}
+			for k, v := range want {
+				if labels[k] != v {
+					t.Errorf("label %s = %q, want %q", k, labels[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestRunOptions_Validate_metricsRequiresEndpoint(t *testing.T) {
+	cmd := NewCmdRun(printers.DefaultOSStreams())
+	cmd.SetArgs([]string{"--trace-log-file", filepath.Join(t.TempDir(), "traces.jsonl"), "--metrics", "true"})
+	if err := cmd.Execute(); err == nil {
+		t.Error("Execute() expected an error for --metrics without an OTLP endpoint")
+	}
+}
+
+func Test_metricsEndpointForTraceEndpoint(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		want     string
+	}{
+		{endpoint: "http://collector:4318/v1/traces", want: "http://collector:4318/v1/metrics"},
+		{endpoint: "http://collector/custom", want: "http://collector/v1/metrics"},
+		{endpoint: "https://collector:4318/otlp/v1/traces?tenant=a", want: "https://collector:4318/v1/metrics?tenant=a"},
+		{endpoint: "collector:4318", want: "collector:4318"},
+		{endpoint: "collector:4318/custom", want: "collector:4318"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.endpoint, func(t *testing.T) {
+			if got := metricsEndpointForTraceEndpoint(tt.endpoint); got != tt.want {
+				t.Errorf("metricsEndpointForTraceEndpoint() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunOptions_Run_metricsCustomTracesPath(t *testing.T) {
+	var mu sync.Mutex
+	paths := make([]string, 0)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		paths = append(paths, r.URL.Path)
+	}))
+	defer ts.Close()
+	t.Setenv(envOTLPTracesEndpoint, ts.URL+"/custom")
+
+	cmd := NewCmdRun(printers.DefaultOSStreams())
+	cmd.SetArgs([]string{"--metrics", "--span-delay", "0s", "true"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	sort.Strings(paths)
+	if want := []string{"/custom", otlpmetrics.DefaultMetricsPath}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("collector got requests for %v, want %v", paths, want)
+	}
+}
@@ -0,0 +1,231 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/davidalpert/opentracer/internal/redact"
+	"github.com/davidalpert/opentracer/internal/utils"
+	"github.com/spf13/pflag"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// supported --capture-output values
+const (
+	captureOutputNone   = "none"
+	captureOutputStderr = "stderr"
+	captureOutputBoth   = "both"
+)
+
+var captureOutputModes = []string{captureOutputNone, captureOutputStderr, captureOutputBoth}
+
+// supported --capture-when values
+const (
+	captureWhenFailure = "failure"
+	captureWhenAlways  = "always"
+)
+
+var captureWhenModes = []string{captureWhenFailure, captureWhenAlways}
+
+// captureWaitDelay bounds how long to wait for captured streams to close once the command
+// exits, e.g. when it left a background process holding them open
+const captureWaitDelay = time.Second
+
+// outputEventName names the span event carrying the tail of a captured stream
+const outputEventName = "output"
+
+// attribute keys describing a captured stream
+const (
+	outputStreamKey    = attribute.Key("opentracer.output.stream")
+	outputTailKey      = attribute.Key("opentracer.output.tail")
+	outputLinesKey     = attribute.Key("opentracer.output.lines")
+	outputBytesKey     = attribute.Key("opentracer.output.bytes")
+	outputTruncatedKey = attribute.Key("opentracer.output.truncated")
+)
+
+// CaptureOptions configures attaching the tail of the command's output to its span
+type CaptureOptions struct {
+	CaptureOutput    string
+	CaptureTailLines int
+	CaptureWhen      string
+	CaptureMaxBytes  int
+}
+
+// AddCaptureFlags binds the output capture flags to the given FlagSet
+func (o *CaptureOptions) AddCaptureFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&o.CaptureOutput, "capture-output", captureOutputNone, fmt.Sprintf("attach the tail of the command's output to the span; one of: %s", strings.Join(captureOutputModes, ", ")))
+	flags.IntVar(&o.CaptureTailLines, "capture-tail-lines", 20, "how many lines of each captured stream to attach")
+	flags.StringVar(&o.CaptureWhen, "capture-when", captureWhenFailure, fmt.Sprintf("when to attach captured output; one of: %s", strings.Join(captureWhenModes, ", ")))
+	flags.IntVar(&o.CaptureMaxBytes, "capture-max-bytes", 4096, "the most bytes of each captured stream to attach; older lines are dropped first")
+}
+
+// Validate validates the CaptureOptions
+func (o *CaptureOptions) Validate() error {
+	if !utils.StringInSlice(captureOutputModes, o.CaptureOutput) {
+		return fmt.Errorf("--capture-output must be one of: %s", strings.Join(captureOutputModes, ", "))
+	}
+	if !utils.StringInSlice(captureWhenModes, o.CaptureWhen) {
+		return fmt.Errorf("--capture-when must be one of: %s", strings.Join(captureWhenModes, ", "))
+	}
+	if o.CaptureTailLines < 1 {
+		return fmt.Errorf("--capture-tail-lines must be at least 1")
+	}
+	if o.CaptureMaxBytes < 1 {
+		return fmt.Errorf("--capture-max-bytes must be at least 1")
+	}
+	return nil
+}
+
+// outputCapture holds the tails of the streams captured from one command
+type outputCapture struct {
+	always bool
+	stdout *tailWriter
+	stderr *tailWriter
+}
+
+// captureOutput tees the configured streams of c (which must not be started yet) into
+// tail buffers; it returns nil when --capture-output is none
+func (o *CaptureOptions) captureOutput(c *exec.Cmd) *outputCapture {
+	if o.CaptureOutput == captureOutputNone || o.CaptureOutput == "" {
+		return nil
+	}
+	capture := &outputCapture{always: o.CaptureWhen == captureWhenAlways}
+	capture.stderr = newTailWriter(o.CaptureTailLines, o.CaptureMaxBytes)
+	c.Stderr = io.MultiWriter(c.Stderr, capture.stderr)
+	if o.CaptureOutput == captureOutputBoth {
+		capture.stdout = newTailWriter(o.CaptureTailLines, o.CaptureMaxBytes)
+		c.Stdout = io.MultiWriter(c.Stdout, capture.stdout)
+	}
+	c.WaitDelay = captureWaitDelay
+	return capture
+}
+
+// record adds an "output" event per captured stream, redacted by r, to span when the command
+// failed or --capture-when is always
+func (capture *outputCapture) record(span trace.Span, failed bool, r *redact.Redactor) {
+	if capture == nil || !(failed || capture.always) {
+		return
+	}
+	if capture.stdout != nil {
+		capture.stdout.record(span, "stdout", r)
+	}
+	capture.stderr.record(span, "stderr", r)
+}
+
+// tailWriter keeps the last lines written to it, bounded by a line count and a byte size,
+// along with the total number of bytes and lines written; truncated is set once anything
+// written is no longer in the tail
+type tailWriter struct {
+	mu        sync.Mutex
+	maxLines  int
+	maxBytes  int
+	lines     []string
+	size      int
+	partial   []byte
+	bytes     int
+	lineCount int
+	truncated bool
+}
+
+func newTailWriter(maxLines, maxBytes int) *tailWriter {
+	return &tailWriter{maxLines: maxLines, maxBytes: maxBytes}
+}
+
+// Write implements io.Writer
+func (w *tailWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	n := len(p)
+	w.bytes += n
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			w.partial = append(w.partial, p...)
+			if len(w.partial) > w.maxBytes {
+				w.partial = []byte(lastBytes(string(w.partial), w.maxBytes))
+				w.truncated = true
+			}
+			break
+		}
+		w.partial = append(w.partial, p[:i]...)
+		w.pushLine(string(w.partial))
+		w.partial = w.partial[:0]
+		p = p[i+1:]
+	}
+	return n, nil
+}
+
+// pushLine adds a complete line, dropping the oldest lines to stay within bounds
+func (w *tailWriter) pushLine(line string) {
+	w.lineCount++
+	if len(line) > w.maxBytes {
+		line = lastBytes(line, w.maxBytes)
+		w.truncated = true
+	}
+	w.lines = append(w.lines, line)
+	w.size += len(line) + 1
+	for len(w.lines) > w.maxLines || w.size > w.maxBytes {
+		w.size -= len(w.lines[0]) + 1
+		w.lines = w.lines[1:]
+		w.truncated = true
+	}
+}
+
+// tail returns the retained lines, including an unterminated last line, within maxLines
+// and maxBytes
+func (w *tailWriter) tail() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	lines := w.lines
+	size := w.size - 1
+	if len(w.partial) > 0 {
+		lines = append(lines[:len(lines):len(lines)], string(w.partial))
+		size += len(w.partial) + 1
+	}
+	for len(lines) > 1 && (len(lines) > w.maxLines || size > w.maxBytes) {
+		size -= len(lines[0]) + 1
+		lines = lines[1:]
+		w.truncated = true
+	}
+	if len(lines) == 1 && len(lines[0]) > w.maxBytes {
+		lines[0] = lastBytes(lines[0], w.maxBytes)
+		w.truncated = true
+	}
+	return strings.Join(lines, "\n")
+}
+
+// lastBytes returns the end of s which fits in max bytes without splitting a UTF-8 character
+func lastBytes(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	i := len(s) - max
+	for i < len(s) && !utf8.RuneStart(s[i]) {
+		i++
+	}
+	return s[i:]
+}
+
+func (w *tailWriter) record(span trace.Span, stream string, r *redact.Redactor) {
+	tail := r.String(w.tail())
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	lines := w.lineCount
+	if len(w.partial) > 0 {
+		lines++
+	}
+	span.AddEvent(outputEventName, trace.WithAttributes(
+		outputStreamKey.String(stream),
+		outputTailKey.String(tail),
+		outputLinesKey.Int(lines),
+		outputBytesKey.Int(w.bytes),
+		outputTruncatedKey.Bool(w.truncated),
+	))
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/davidalpert/go-printers/v1"
+)
+
+func Test_tailWriter(t *testing.T) {
+	tests := []struct {
+		name          string
+		maxLines      int
+		maxBytes      int
+		writes        []string
+		wantTail      string
+		wantTruncated bool
+	}{
+		{name: "everything fits", maxLines: 5, maxBytes: 100, writes: []string{"one\ntwo\n"}, wantTail: "one\ntwo"},
+		{name: "lines split across writes", maxLines: 5, maxBytes: 100, writes: []string{"o", "ne\ntw", "o"}, wantTail: "one\ntwo"},
+		{name: "keeps the last lines", maxLines: 2, maxBytes: 100, writes: []string{"one\ntwo\nthree\n"}, wantTail: "two\nthree", wantTruncated: true},
+		{name: "keeps the last bytes", maxLines: 5, maxBytes: 10, writes: []string{"one\ntwo\nthree\n"}, wantTail: "two\nthree", wantTruncated: true},
+		{name: "cuts a long line", maxLines: 5, maxBytes: 4, writes: []string{"0123456789"}, wantTail: "6789", wantTruncated: true},
+		{name: "unterminated line counts toward the bytes", maxLines: 5, maxBytes: 10, writes: []string{"one\ntwo\nthree"}, wantTail: "two\nthree", wantTruncated: true},
+		{name: "unterminated line counts toward the lines", maxLines: 2, maxBytes: 100, writes: []string{"one\ntwo\nthree"}, wantTail: "two\nthree", wantTruncated: true},
+		{name: "long unterminated line after a full tail", maxLines: 5, maxBytes: 8, writes: []string{"abcdefg\n", "0123456789"}, wantTail: "23456789", wantTruncated: true},
+		{name: "cuts a line on a character boundary", maxLines: 5, maxBytes: 4, writes: []string{"héllo wörld\n"}, wantTail: "rld", wantTruncated: true},
+		{name: "cuts an unterminated line on a character boundary", maxLines: 5, maxBytes: 5, writes: []string{"日本語"}, wantTail: "語", wantTruncated: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newTailWriter(tt.maxLines, tt.maxBytes)
+			for _, s := range tt.writes {
+				if n, err := w.Write([]byte(s)); n != len(s) || err != nil {
+					t.Fatalf("Write() = (%d, %v), want (%d, nil)", n, err, len(s))
+				}
+			}
+			if got := w.tail(); got != tt.wantTail {
+				t.Errorf("tail() = %q, want %q", got, tt.wantTail)
+			}
+			if w.truncated != tt.wantTruncated {
+				t.Errorf("truncated = %v, want %v", w.truncated, tt.wantTruncated)
+			}
+		})
+	}
+}
+
+func TestRunOptions_Run_captureOutput(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		script     string
+		wantEvents map[string]string
+	}{
+		{name: "none", args: []string{"--capture-output", "none"}, script: "echo err >&2; exit 1", wantEvents: map[string]string{}},
+		{name: "stderr on failure", args: []string{"--capture-output", "stderr", "--capture-tail-lines", "1"}, script: "echo out; echo err1 >&2; echo err2 >&2; exit 1", wantEvents: map[string]string{"stderr": "err2"}},
+		{name: "both on failure", args: []string{"--capture-output", "both"}, script: "echo out; echo err >&2; exit 1", wantEvents: map[string]string{"stdout": "out", "stderr": "err"}},
+		{name: "not on success", args: []string{"--capture-output", "both"}, script: "echo out", wantEvents: map[string]string{}},
+		{name: "always", args: []string{"--capture-output", "both", "--capture-when", "always"}, script: "echo out", wantEvents: map[string]string{"stdout": "out", "stderr": ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logFile := filepath.Join(t.TempDir(), "traces.jsonl")
+			cmd := NewCmdRun(printers.DefaultOSStreams())
+			args := append([]string{"--trace-log-file", logFile, "--span-delay", "0s"}, tt.args...)
+			cmd.SetArgs(append(args, "sh", "--", "-c", tt.script))
+			if _, ok := exitCodeOf(cmd.Execute()); !ok {
+				t.Fatal("Execute() did not run the command")
+			}
+
+			gotEvents := map[string]string{}
+			for _, e := range readLoggedSpans(t, logFile)[0].Events {
+				if e.Name != outputEventName {
+					continue
+				}
+				var stream, tail string
+				for _, kv := range e.Attributes {
+					switch kv.Key {
+					case string(outputStreamKey):
+						stream = kv.Value.GetStringValue()
+					case string(outputTailKey):
+						tail = kv.Value.GetStringValue()
+					}
+				}
+				gotEvents[stream] = tail
+			}
+			if len(gotEvents) != len(tt.wantEvents) {
+				t.Fatalf("output events = %v, want %v", gotEvents, tt.wantEvents)
+			}
+			for stream, want := range tt.wantEvents {
+				if gotEvents[stream] != want {
+					t.Errorf("%s tail = %q, want %q", stream, gotEvents[stream], want)
+				}
+			}
+		})
+	}
+}
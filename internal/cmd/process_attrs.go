@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+
+	"github.com/davidalpert/opentracer/internal/redact"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+)
+
+// supported --include-process-attrs levels
+const (
+	processAttrsNone  = "none"
+	processAttrsBasic = "basic"
+	processAttrsFull  = "full"
+)
+
+var processAttrsLevels = []string{processAttrsNone, processAttrsBasic, processAttrsFull}
+
+// attribute keys describing the wrapped process which semconv v1.7.0 does not define
+const (
+	processParentPIDKey        = attribute.Key("process.parent_pid")
+	processWorkingDirectoryKey = attribute.Key("process.working_directory")
+)
+
+// processAttributes describes the started command c at the given --include-process-attrs
+// level; command arguments are only included at the full level, redacted by r
+func processAttributes(c *exec.Cmd, level string, r *redact.Redactor) []attribute.KeyValue {
+	if level == processAttrsNone || c.Process == nil {
+		return nil
+	}
+	attrs := []attribute.KeyValue{
+		semconv.ProcessPIDKey.Int(c.Process.Pid),
+		processParentPIDKey.Int(os.Getpid()),
+		semconv.ProcessExecutableNameKey.String(filepath.Base(c.Path)),
+		semconv.ProcessExecutablePathKey.String(c.Path),
+	}
+	if u, err := user.Current(); err == nil {
+		attrs = append(attrs, semconv.ProcessOwnerKey.String(u.Username))
+	}
+	dir := c.Dir
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+	if dir != "" {
+		attrs = append(attrs, processWorkingDirectoryKey.String(dir))
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		attrs = append(attrs, semconv.HostNameKey.String(hostname))
+	}
+	if level == processAttrsFull {
+		attrs = append(attrs, semconv.ProcessCommandArgsKey.StringSlice(r.Args(c.Args)))
+	}
+	return attrs
+}
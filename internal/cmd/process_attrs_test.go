@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/davidalpert/go-printers/v1"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+)
+
+func TestRunOptions_Run_includeProcessAttrs(t *testing.T) {
+	tests := []struct {
+		level     string
+		wantBasic bool
+		wantArgs  []string
+	}{
+		{level: processAttrsNone},
+		{level: processAttrsBasic, wantBasic: true},
+		{level: processAttrsFull, wantBasic: true, wantArgs: []string{"sh", "-c", "true", "--token", redactedValue}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.level, func(t *testing.T) {
+			logFile := filepath.Join(t.TempDir(), "traces.jsonl")
+			cmd := NewCmdRun(printers.DefaultOSStreams())
+			cmd.SetArgs([]string{"--trace-log-file", logFile, "--span-delay", "0s", "--include-process-attrs", tt.level, "sh", "--", "-c", "true", "--token", "abc"})
+			if err := cmd.Execute(); err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+
+			span := readLoggedSpans(t, logFile)[0]
+			_, hasPID := spanAttribute(span, string(semconv.ProcessPIDKey))
+			if hasPID != tt.wantBasic {
+				t.Errorf("has %s = %v, want %v", semconv.ProcessPIDKey, hasPID, tt.wantBasic)
+			}
+			if tt.wantBasic {
+				if got, _ := spanAttribute(span, string(processParentPIDKey)); got != strconv.Itoa(os.Getpid()) {
+					t.Errorf("%s = %s, want %d", processParentPIDKey, got, os.Getpid())
+				}
+			}
+
+			var gotArgs []string
+			for _, kv := range span.Attributes {
+				if kv.Key == string(semconv.ProcessCommandArgsKey) {
+					for _, v := range kv.Value.GetArrayValue().Values {
+						gotArgs = append(gotArgs, v.GetStringValue())
+					}
+				}
+			}
+			if !reflect.DeepEqual(gotArgs, tt.wantArgs) {
+				t.Errorf("%s = %v, want %v", semconv.ProcessCommandArgsKey, gotArgs, tt.wantArgs)
+			}
+		})
+	}
+}
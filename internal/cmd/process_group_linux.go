@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// processGroupRunning reports whether any member of process group pgid is alive; zombies
+// are skipped because an init which is slow to reap orphans would otherwise keep the group
+// looking alive
+func processGroupRunning(pgid int) (running bool, ok bool) {
+	stats, err := filepath.Glob("/proc/[0-9]*/stat")
+	if err != nil || len(stats) == 0 {
+		return false, false
+	}
+	for _, path := range stats {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			// the process exited while we looked
+			continue
+		}
+		// the state and process group follow the parenthesised command name
+		fields := strings.Fields(string(b[strings.LastIndexByte(string(b), ')')+1:]))
+		if len(fields) < 3 || fields[0] == "Z" {
+			continue
+		}
+		if g, err := strconv.Atoi(fields[2]); err == nil && g == pgid {
+			return true, true
+		}
+	}
+	return false, true
+}
@@ -0,0 +1,9 @@
+//go:build unix && !linux
+
+package cmd
+
+// processGroupRunning cannot tell zombies apart here so commandRunning falls back to
+// signalling the group
+func processGroupRunning(pgid int) (running bool, ok bool) {
+	return false, false
+}
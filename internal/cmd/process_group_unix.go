@@ -0,0 +1,59 @@
+//go:build unix
+
+package cmd
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// startInProcessGroup makes c the leader of a process group of its own so that a timeout
+// also stops anything the command started; a command whose stdin is a terminal stays in the
+// terminal's foreground group since a background group is stopped (SIGTTIN) when it reads
+func startInProcessGroup(c *exec.Cmd) {
+	if isTerminal(c.Stdin) {
+		return
+	}
+	c.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// isTerminal reports whether r is a terminal
+func isTerminal(r io.Reader) bool {
+	f, ok := r.(*os.File)
+	if !ok {
+		return false
+	}
+	_, err := unix.IoctlGetTermios(int(f.Fd()), ioctlReadTermios)
+	return err == nil
+}
+
+// signalCommand signals the started command c, or its whole process group when it was
+// started in one of its own so that grandchildren are not left behind
+func signalCommand(c *exec.Cmd, sig os.Signal) error {
+	if s, ok := sig.(syscall.Signal); ok && inProcessGroup(c) {
+		return syscall.Kill(-c.Process.Pid, s)
+	}
+	return c.Process.Signal(sig)
+}
+
+// commandRunning reports whether anything started by c is still running; for a process
+// group that includes grandchildren which outlive the command itself
+func commandRunning(c *exec.Cmd, exited bool) bool {
+	if inProcessGroup(c) {
+		if running, ok := processGroupRunning(c.Process.Pid); ok {
+			return running
+		}
+		err := syscall.Kill(-c.Process.Pid, 0)
+		return err == nil || errors.Is(err, syscall.EPERM)
+	}
+	return !exited
+}
+
+func inProcessGroup(c *exec.Cmd) bool {
+	return c.SysProcAttr != nil && c.SysProcAttr.Setpgid
+}
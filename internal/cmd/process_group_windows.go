@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+)
+
+// startInProcessGroup does nothing on windows, which has no process groups to signal
+func startInProcessGroup(c *exec.Cmd) {}
+
+// signalCommand stops the started command c; windows cannot deliver signals to another
+// process so anything but an interrupt, which the console already sent to the command,
+// kills it
+func signalCommand(c *exec.Cmd, sig os.Signal) error {
+	if sig == os.Interrupt {
+		return nil
+	}
+	return c.Process.Kill()
+}
+
+// commandRunning reports whether c is still running
+func commandRunning(c *exec.Cmd, exited bool) bool {
+	return !exited
+}
@@ -0,0 +1,286 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/davidalpert/opentracer/internal/b3"
+	"github.com/davidalpert/opentracer/internal/jaeger"
+	"github.com/davidalpert/opentracer/internal/redact"
+	"github.com/davidalpert/opentracer/internal/w3c"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// environment variables carrying trace context between processes; TRACEPARENT, TRACESTATE
+// and BAGGAGE follow the OpenTelemetry environment carrier convention while W3CTRACEPARENT
+// is opentracer's own
+const (
+	envTraceparent    = "TRACEPARENT"
+	envTracestate     = "TRACESTATE"
+	envBaggage        = "BAGGAGE"
+	envW3CTraceparent = "W3CTRACEPARENT"
+	envW3CTracestate  = "W3CTRACESTATE"
+)
+
+// baggageHeader is the W3C baggage header name used by propagation.Baggage
+const baggageHeader = "baggage"
+
+// supported --propagators values, named as in OTEL_PROPAGATORS
+const (
+	propagatorTraceContext = "tracecontext"
+	propagatorBaggage      = "baggage"
+	propagatorB3           = "b3"
+	propagatorB3Multi      = "b3multi"
+	propagatorJaeger       = "jaeger"
+	propagatorNone         = "none"
+)
+
+var propagatorNames = []string{propagatorTraceContext, propagatorBaggage, propagatorB3, propagatorB3Multi, propagatorJaeger, propagatorNone}
+
+var defaultPropagators = []string{propagatorTraceContext, propagatorBaggage}
+
+var propagatorsByName = map[string]propagation.TextMapPropagator{
+	propagatorTraceContext: propagation.TraceContext{},
+	propagatorBaggage:      propagation.Baggage{},
+	propagatorB3:           b3.SingleHeader{},
+	propagatorB3Multi:      b3.MultipleHeader{},
+	propagatorJaeger:       jaeger.Propagator{},
+}
+
+// newPropagator combines the named propagators; when several of them find a parent span
+// context the last one wins
+func newPropagator(names []string) (propagation.TextMapPropagator, error) {
+	propagators := make([]propagation.TextMapPropagator, 0, len(names))
+	for _, name := range names {
+		if name == propagatorNone {
+			continue
+		}
+		p, ok := propagatorsByName[name]
+		if !ok {
+			return nil, fmt.Errorf("--propagators must be a list of: %s", strings.Join(propagatorNames, ", "))
+		}
+		propagators = append(propagators, p)
+	}
+	return propagation.NewCompositeTextMapPropagator(propagators...), nil
+}
+
+// envForField names the environment variable carrying a propagation field (header), e.g.
+// X_B3_TRACEID for x-b3-traceid
+func envForField(field string) string {
+	return strings.ToUpper(strings.ReplaceAll(field, "-", "_"))
+}
+
+// parentCarrierFromEnvironment collects the inherited trace context for the fields p
+// reads; TRACEPARENT takes precedence over W3CTRACEPARENT when both are set
+func parentCarrierFromEnvironment(p propagation.TextMapPropagator) propagation.MapCarrier {
+	carrier := propagation.MapCarrier{}
+	for _, field := range p.Fields() {
+		if field == w3c.TraceparentHeader {
+			if v := os.Getenv(envW3CTraceparent); v != "" {
+				carrier[field] = v
+			}
+		}
+		if v := os.Getenv(envForField(field)); v != "" {
+			carrier[field] = v
+		}
+	}
+	return carrier
+}
+
+// traceparentSource names the variable parentCarrierFromEnvironment took the traceparent from
+func traceparentSource() string {
+	if os.Getenv(envTraceparent) != "" {
+		return envTraceparent
+	}
+	return envW3CTraceparent
+}
+
+// extractParentContext returns a context holding the trace context and baggage inherited
+// through the environment, if any
+func (o *RunOptions) extractParentContext() context.Context {
+	carrier := parentCarrierFromEnvironment(o.propagator)
+	if v, ok := carrier[w3c.TraceparentHeader]; ok {
+		if tp, err := w3c.ParseTraceParent(v); err != nil {
+			// the spec discards the tracestate along with a malformed traceparent
+			fmt.Fprintf(o.ErrOut, "opentracer: ignoring malformed %s, starting a new trace: %s\n", traceparentSource(), err)
+			delete(carrier, w3c.TraceparentHeader)
+			delete(carrier, w3c.TracestateHeader)
+		} else {
+			// normalize to version 00 so the propagator accepts what ParseTraceParent accepted
+			carrier[w3c.TraceparentHeader] = w3c.NewTraceParentFromSpanContext(tp.SpanContext()).String()
+		}
+	}
+	if v, ok := carrier[w3c.TracestateHeader]; ok {
+		// a malformed tracestate must not break the trace so drop it and keep the traceparent
+		if _, err := w3c.ParseTraceState(v); err != nil {
+			fmt.Fprintf(o.ErrOut, "opentracer: ignoring malformed %s: %s\n", envTracestate, err)
+			delete(carrier, w3c.TracestateHeader)
+		}
+	}
+	if v, ok := carrier[baggageHeader]; ok {
+		if _, err := baggage.Parse(v); err != nil {
+			fmt.Fprintf(o.ErrOut, "opentracer: ignoring malformed %s: %s\n", envBaggage, err)
+			delete(carrier, baggageHeader)
+		}
+	}
+	if o.Debug {
+		for _, k := range carrier.Keys() {
+			fmt.Printf("------------------------------------------------------------------------------------\n")
+			fmt.Printf("found %s: %s\n", k, o.redactor.String(carrier.Get(k)))
+		}
+	}
+	ctx := o.propagator.Extract(context.Background(), carrier)
+	if len(o.baggage) == 0 {
+		return ctx
+	}
+	b := baggage.FromContext(ctx)
+	for _, m := range o.baggage {
+		// members were validated by parseBaggageEntries so this cannot fail
+		b, _ = b.SetMember(m)
+	}
+	return baggage.ContextWithBaggage(ctx, b)
+}
+
+// staleFields names fields which describe the inherited parent but which the propagator
+// writing the keyed field never sets; they are cleared along with that propagator's fields so
+// the command does not see a stale parent (e.g. X_B3_PARENTSPANID next to a new X_B3_SPANID)
+var staleFields = map[string][]string{
+	b3.TraceIDHeader: {b3.ParentSpanIDHeader},
+}
+
+// appendPropagationToEnv adds the variables p writes (e.g. TRACEPARENT, TRACESTATE and
+// BAGGAGE) describing the span in ctx so that tools other than opentracer continue the trace;
+// inherited copies of every supported propagator's variables are removed first so that the
+// command never sees a stale parent, whether p leaves a field empty (e.g. a malformed
+// TRACESTATE which was dropped, or X_B3_FLAGS) or does not write it at all (e.g. TRACEPARENT
+// with --propagators b3, which would make a nested opentracer attach to the grandparent)
+func appendPropagationToEnv(ctx context.Context, p propagation.TextMapPropagator, ss []string) []string {
+	carrier := propagation.MapCarrier{}
+	p.Inject(ctx, carrier)
+
+	cleared := make(map[string]bool)
+	for _, known := range propagatorsByName {
+		for _, field := range known.Fields() {
+			cleared[envForField(field)] = true
+			for _, stale := range staleFields[field] {
+				cleared[envForField(stale)] = true
+			}
+		}
+	}
+	env := make([]string, 0, len(ss)+len(carrier))
+	for _, e := range ss {
+		if name := strings.SplitN(e, "=", 2)[0]; !cleared[name] {
+			env = append(env, e)
+		}
+	}
+	for _, field := range p.Fields() {
+		if v := carrier.Get(field); v != "" {
+			env = append(env, fmt.Sprintf("%s=%s", envForField(field), v))
+		}
+	}
+	return env
+}
+
+// injectPropagationTokens replaces $NAME and ${NAME} for each variable p writes
+// (e.g. $B3 or $X_B3_TRACEID) with its value for the span in ctx
+func injectPropagationTokens(ctx context.Context, p propagation.TextMapPropagator, s string) string {
+	carrier := propagation.MapCarrier{}
+	p.Inject(ctx, carrier)
+	for _, field := range p.Fields() {
+		name := envForField(field)
+		s = strings.Replace(s, "${"+name+"}", carrier.Get(field), -1)
+		s = strings.Replace(s, "$"+name, carrier.Get(field), -1)
+	}
+	return s
+}
+
+// parseTraceStateEntries parses --tracestate key=value entries
+func parseTraceStateEntries(entries []string) ([]w3c.TraceStateMember, error) {
+	members := make([]w3c.TraceStateMember, 0, len(entries))
+	for _, e := range entries {
+		parts := strings.SplitN(e, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("--tracestate must be in the format key=value: '%s'", e)
+		}
+		m, err := w3c.NewTraceStateMember(parts[0], parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("--tracestate: %w", err)
+		}
+		members = append(members, m)
+	}
+	return members, nil
+}
+
+// traceStateSampler samples like its parent-based delegate and adds the --tracestate
+// entries to the tracestate of every span it starts
+type traceStateSampler struct {
+	sdktrace.Sampler
+	members []w3c.TraceStateMember
+}
+
+// newTraceStateSampler returns a sampler which follows the parent's sampling decision
+func newTraceStateSampler(members []w3c.TraceStateMember) sdktrace.Sampler {
+	return traceStateSampler{
+		Sampler: sdktrace.ParentBased(sdktrace.AlwaysSample()),
+		members: members,
+	}
+}
+
+// ShouldSample implements sdktrace.Sampler
+func (s traceStateSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	result := s.Sampler.ShouldSample(p)
+	ts, err := w3c.ParseTraceState(result.Tracestate.String())
+	if err != nil {
+		return result
+	}
+	// insert in reverse so that the first --tracestate entry ends up left-most
+	for i := len(s.members) - 1; i >= 0; i-- {
+		if ts, err = ts.Insert(s.members[i].Key, s.members[i].Value); err != nil {
+			return result
+		}
+	}
+	if tracestate, err := ts.OpenTelemetry(); err == nil {
+		result.Tracestate = tracestate
+	}
+	return result
+}
+
+// Description implements sdktrace.Sampler
+func (s traceStateSampler) Description() string {
+	return fmt.Sprintf("TraceStateSampler{%s}", s.Sampler.Description())
+}
+
+// parseBaggageEntries parses --baggage key=value entries
+func parseBaggageEntries(entries []string) ([]baggage.Member, error) {
+	members := make([]baggage.Member, 0, len(entries))
+	for _, e := range entries {
+		parts := strings.SplitN(e, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("--baggage must be in the format key=value: '%s'", e)
+		}
+		m, err := baggage.NewMember(parts[0], parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("--baggage: %w", err)
+		}
+		members = append(members, m)
+	}
+	return members, nil
+}
+
+// baggageAttributes returns the baggage entries named by keys as span attributes; keys
+// which are not in the baggage are skipped
+func baggageAttributes(ctx context.Context, keys []string, r *redact.Redactor) []attribute.KeyValue {
+	b := baggage.FromContext(ctx)
+	attrs := make([]attribute.KeyValue, 0, len(keys))
+	for _, k := range keys {
+		if m := b.Member(k); m.Key() != "" {
+			attrs = append(attrs, attribute.String(k, r.String(m.Value())))
+		}
+	}
+	return attrs
+}
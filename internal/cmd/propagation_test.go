@@ -0,0 +1,546 @@
+package cmd
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/davidalpert/go-printers/v1"
+	"go.opentelemetry.io/otel/baggage"
+)
+
+const (
+	testTraceparent    = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	testW3CTraceparent = "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"
+)
+
+func TestRunOptions_Run_propagatesEnvironmentContext(t *testing.T) {
+	tests := []struct {
+		name           string
+		env            map[string]string
+		wantTraceID    string
+		wantParentID   string
+		wantTracestate string
+		wantBaggage    string
+	}{
+		{name: "no parent"},
+		{name: "W3CTRACEPARENT", env: map[string]string{envW3CTraceparent: testW3CTraceparent}, wantTraceID: "0af7651916cd43dd8448eb211c80319c", wantParentID: "b7ad6b7169203331"},
+		{name: "TRACEPARENT", env: map[string]string{envTraceparent: testTraceparent}, wantTraceID: "4bf92f3577b34da6a3ce929d0e0e4736", wantParentID: "00f067aa0ba902b7"},
+		{name: "TRACEPARENT wins", env: map[string]string{envTraceparent: testTraceparent, envW3CTraceparent: testW3CTraceparent}, wantTraceID: "4bf92f3577b34da6a3ce929d0e0e4736", wantParentID: "00f067aa0ba902b7"},
+		{
+			name:           "TRACESTATE and BAGGAGE",
+			env:            map[string]string{envTraceparent: testTraceparent, envTracestate: "lb=a1b2,vendor=x", envBaggage: "tenant=acme"},
+			wantTraceID:    "4bf92f3577b34da6a3ce929d0e0e4736",
+			wantParentID:   "00f067aa0ba902b7",
+			wantTracestate: "lb=a1b2,vendor=x",
+			wantBaggage:    "tenant=acme",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, k := range []string{envTraceparent, envTracestate, envBaggage, envW3CTraceparent} {
+				t.Setenv(k, tt.env[k])
+			}
+			dir := t.TempDir()
+			logFile := filepath.Join(dir, "traces.jsonl")
+			outFile := filepath.Join(dir, "out")
+			cmd := NewCmdRun(printers.DefaultOSStreams())
+			cmd.SetArgs([]string{"--trace-log-file", logFile, "--span-delay", "0s", "sh", "--", "-c", `echo "$TRACEPARENT|$TRACESTATE|$BAGGAGE" > ` + outFile})
+			if err := cmd.Execute(); err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+
+			span := readLoggedSpans(t, logFile)[0]
+			traceID := hex.EncodeToString(span.TraceId)
+			if tt.wantTraceID != "" && traceID != tt.wantTraceID {
+				t.Errorf("trace id = %s, want %s", traceID, tt.wantTraceID)
+			}
+			if got := hex.EncodeToString(span.ParentSpanId); got != tt.wantParentID {
+				t.Errorf("parent span id = %q, want %q", got, tt.wantParentID)
+			}
+
+			b, err := os.ReadFile(outFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := strings.Split(strings.TrimSpace(string(b)), "|")
+			wantTraceparent := "00-" + traceID + "-" + hex.EncodeToString(span.SpanId) + "-01"
+			if got[0] != wantTraceparent {
+				t.Errorf("child TRACEPARENT = %q, want %q", got[0], wantTraceparent)
+			}
+			if got[1] != tt.wantTracestate {
+				t.Errorf("child TRACESTATE = %q, want %q", got[1], tt.wantTracestate)
+			}
+			if got[2] != tt.wantBaggage {
+				t.Errorf("child BAGGAGE = %q, want %q", got[2], tt.wantBaggage)
+			}
+		})
+	}
+}
+
+func TestRunOptions_Run_propagatesTraceState(t *testing.T) {
+	tests := []struct {
+		name           string
+		tracestate     string
+		args           []string
+		wantTracestate string
+		wantWarning    bool
+	}{
+		{name: "inherited", tracestate: "lb=a1b2,vendor=x", wantTracestate: "lb=a1b2,vendor=x"},
+		{name: "added", args: []string{"--tracestate", "ot=1", "--tracestate", "ci=build-7"}, wantTracestate: "ot=1,ci=build-7"},
+		{name: "added before inherited", tracestate: "lb=a1b2,ot=0", args: []string{"--tracestate", "ot=1"}, wantTracestate: "ot=1,lb=a1b2"},
+		{name: "malformed", tracestate: "lb=a1b2,Not Valid", wantWarning: true},
+		{name: "malformed with added", tracestate: "lb", args: []string{"--tracestate", "ot=1"}, wantTracestate: "ot=1", wantWarning: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(envTraceparent, testTraceparent)
+			t.Setenv(envTracestate, tt.tracestate)
+			dir := t.TempDir()
+			logFile := filepath.Join(dir, "traces.jsonl")
+			outFile := filepath.Join(dir, "out")
+			s, _, _, errOut := printers.NewTestIOStreams()
+			cmd := NewCmdRun(s)
+			args := append([]string{"--trace-log-file", logFile, "--span-delay", "0s"}, tt.args...)
+			cmd.SetArgs(append(args, "sh", "--", "-c", `echo "$W3CTRACESTATE|$TRACESTATE" > `+outFile))
+			if err := cmd.Execute(); err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+
+			span := readLoggedSpans(t, logFile)[0]
+			if span.TraceState != tt.wantTracestate {
+				t.Errorf("span tracestate = %q, want %q", span.TraceState, tt.wantTracestate)
+			}
+			if got := hex.EncodeToString(span.ParentSpanId); got != "00f067aa0ba902b7" {
+				t.Errorf("parent span id = %q, want the TRACEPARENT span", got)
+			}
+			b, err := os.ReadFile(outFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := strings.Split(strings.TrimSpace(string(b)), "|")
+			if got[0] != tt.wantTracestate {
+				t.Errorf("child W3CTRACESTATE = %q, want %q", got[0], tt.wantTracestate)
+			}
+			if got[1] != tt.wantTracestate {
+				t.Errorf("child TRACESTATE = %q, want %q", got[1], tt.wantTracestate)
+			}
+			if gotWarning := strings.Contains(errOut.String(), "ignoring malformed TRACESTATE"); gotWarning != tt.wantWarning {
+				t.Errorf("warning = %v, want %v; stderr: %s", gotWarning, tt.wantWarning, errOut.String())
+			}
+		})
+	}
+}
+
+func TestRunOptions_Validate_tracestate(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []string
+		wantErr bool
+	}{
+		{name: "valid", entries: []string{"ot=1", "tenant@vendor=a-b"}},
+		{name: "missing value", entries: []string{"ot"}, wantErr: true},
+		{name: "invalid key", entries: []string{"Ot=1"}, wantErr: true},
+		{name: "invalid value", entries: []string{"ot=a,b"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseTraceStateEntries(tt.entries); (err != nil) != tt.wantErr {
+				t.Errorf("parseTraceStateEntries() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunOptions_Run_propagatesBaggage(t *testing.T) {
+	tests := []struct {
+		name        string
+		baggage     string
+		args        []string
+		wantBaggage map[string]string
+		wantAttrs   map[string]string
+		wantWarning bool
+	}{
+		{name: "inherited", baggage: "tenant=acme", wantBaggage: map[string]string{"tenant": "acme"}},
+		{name: "added", args: []string{"--baggage", "run=1234"}, wantBaggage: map[string]string{"run": "1234"}},
+		{name: "added replaces inherited", baggage: "tenant=acme,run=1", args: []string{"--baggage", "run=2"}, wantBaggage: map[string]string{"tenant": "acme", "run": "2"}},
+		{
+			name:        "promoted to attributes",
+			baggage:     "tenant=acme,secret=x",
+			args:        []string{"--baggage", "run=1234", "--baggage-attributes", "tenant,run,missing"},
+			wantBaggage: map[string]string{"tenant": "acme", "secret": "x", "run": "1234"},
+			wantAttrs:   map[string]string{"tenant": "acme", "run": "1234"},
+		},
+		{name: "malformed", baggage: "tenant", args: []string{"--baggage", "run=1"}, wantBaggage: map[string]string{"run": "1"}, wantWarning: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(envBaggage, tt.baggage)
+			dir := t.TempDir()
+			logFile := filepath.Join(dir, "traces.jsonl")
+			outFile := filepath.Join(dir, "out")
+			s, _, _, errOut := printers.NewTestIOStreams()
+			cmd := NewCmdRun(s)
+			args := append([]string{"--trace-log-file", logFile, "--span-delay", "0s"}, tt.args...)
+			cmd.SetArgs(append(args, "sh", "--", "-c", `echo "$(printenv BAGGAGE)|$BAGGAGE" > `+outFile))
+			if err := cmd.Execute(); err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+
+			b, err := os.ReadFile(outFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := strings.Split(strings.TrimSpace(string(b)), "|")
+			for i, name := range []string{"child BAGGAGE", "$BAGGAGE token"} {
+				gotBaggage, err := baggage.Parse(got[i])
+				if err != nil {
+					t.Fatalf("%s = %q: %v", name, got[i], err)
+				}
+				if gotBaggage.Len() != len(tt.wantBaggage) {
+					t.Errorf("%s = %q, want %v", name, got[i], tt.wantBaggage)
+				}
+				for k, v := range tt.wantBaggage {
+					if gotBaggage.Member(k).Value() != v {
+						t.Errorf("%s = %q, want %s=%s", name, got[i], k, v)
+					}
+				}
+			}
+
+			span := readLoggedSpans(t, logFile)[0]
+			for _, k := range []string{"tenant", "run", "secret", "missing"} {
+				gotAttr, ok := spanAttribute(span, k)
+				if wantAttr, want := tt.wantAttrs[k]; ok != want || gotAttr != wantAttr {
+					t.Errorf("attribute %s = %q (%v), want %q (%v)", k, gotAttr, ok, wantAttr, want)
+				}
+			}
+			if gotWarning := strings.Contains(errOut.String(), "ignoring malformed BAGGAGE"); gotWarning != tt.wantWarning {
+				t.Errorf("warning = %v, want %v; stderr: %s", gotWarning, tt.wantWarning, errOut.String())
+			}
+		})
+	}
+}
+
+func TestRunOptions_Validate_baggage(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []string
+		wantErr bool
+	}{
+		{name: "valid", entries: []string{"tenant=acme", "run=1234"}},
+		{name: "missing value", entries: []string{"tenant"}, wantErr: true},
+		{name: "invalid key", entries: []string{"ten ant=acme"}, wantErr: true},
+		{name: "invalid value", entries: []string{"tenant=a,b"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseBaggageEntries(tt.entries); (err != nil) != tt.wantErr {
+				t.Errorf("parseBaggageEntries() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunOptions_Run_malformedTraceparent(t *testing.T) {
+	tests := []struct {
+		name         string
+		env          map[string]string
+		wantParentID string
+		wantWarning  string
+	}{
+		{name: "valid", env: map[string]string{envTraceparent: testTraceparent}, wantParentID: "00f067aa0ba902b7"},
+		{name: "future version", env: map[string]string{envTraceparent: "cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-09-future"}, wantParentID: "00f067aa0ba902b7"},
+		{name: "malformed W3CTRACEPARENT", env: map[string]string{envW3CTraceparent: "00-not-a-traceparent"}, wantWarning: "ignoring malformed W3CTRACEPARENT"},
+		{name: "version ff", env: map[string]string{envW3CTraceparent: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}, wantWarning: "ignoring malformed W3CTRACEPARENT"},
+		{name: "all-zero TRACEPARENT", env: map[string]string{envTraceparent: "00-00000000000000000000000000000000-00f067aa0ba902b7-01", envTracestate: "lb=a1b2"}, wantWarning: "ignoring malformed TRACEPARENT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, k := range []string{envTraceparent, envTracestate, envW3CTraceparent} {
+				t.Setenv(k, tt.env[k])
+			}
+			logFile := filepath.Join(t.TempDir(), "traces.jsonl")
+			s, _, _, errOut := printers.NewTestIOStreams()
+			cmd := NewCmdRun(s)
+			cmd.SetArgs([]string{"--trace-log-file", logFile, "--span-delay", "0s", "true"})
+			if err := cmd.Execute(); err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+
+			span := readLoggedSpans(t, logFile)[0]
+			if got := hex.EncodeToString(span.ParentSpanId); got != tt.wantParentID {
+				t.Errorf("parent span id = %q, want %q", got, tt.wantParentID)
+			}
+			if tt.wantParentID == "" && span.TraceState != "" {
+				t.Errorf("span tracestate = %q, want it discarded", span.TraceState)
+			}
+			if got := errOut.String(); (tt.wantWarning == "" && got != "") || !strings.Contains(got, tt.wantWarning) {
+				t.Errorf("stderr = %q, want %q", got, tt.wantWarning)
+			}
+		})
+	}
+}
+
+func TestRunOptions_Run_propagators(t *testing.T) {
+	const (
+		b3TraceID = "0af7651916cd43dd8448eb211c80319c"
+		b3SpanID  = "b7ad6b7169203331"
+	)
+	tests := []struct {
+		name         string
+		env          map[string]string
+		args         []string
+		wantTraceID  string
+		wantParentID string
+		wantB3       bool
+		wantB3Multi  bool
+		wantTraceCtx bool
+	}{
+		{name: "default ignores b3", env: map[string]string{"B3": b3TraceID + "-" + b3SpanID + "-1"}, wantTraceCtx: true},
+		{name: "b3", env: map[string]string{"B3": b3TraceID + "-" + b3SpanID + "-1"}, args: []string{"--propagators", "b3"}, wantTraceID: b3TraceID, wantParentID: b3SpanID, wantB3: true},
+		{
+			name:         "b3multi",
+			env:          map[string]string{"X_B3_TRACEID": b3TraceID, "X_B3_SPANID": b3SpanID, "X_B3_SAMPLED": "1"},
+			args:         []string{"--propagators", "b3multi"},
+			wantTraceID:  b3TraceID,
+			wantParentID: b3SpanID,
+			wantB3Multi:  true,
+		},
+		{
+			name:         "last propagator wins",
+			env:          map[string]string{envTraceparent: testTraceparent, "B3": b3TraceID + "-" + b3SpanID + "-1"},
+			args:         []string{"--propagators", "tracecontext,b3"},
+			wantTraceID:  b3TraceID,
+			wantParentID: b3SpanID,
+			wantB3:       true,
+			wantTraceCtx: true,
+		},
+		{
+			name:         "OTEL_PROPAGATORS",
+			env:          map[string]string{envPropagators: "tracecontext, b3multi", envTraceparent: testTraceparent},
+			wantTraceID:  "4bf92f3577b34da6a3ce929d0e0e4736",
+			wantParentID: "00f067aa0ba902b7",
+			wantB3Multi:  true,
+			wantTraceCtx: true,
+		},
+		{name: "flag overrides OTEL_PROPAGATORS", env: map[string]string{envPropagators: "b3"}, args: []string{"--propagators", "b3multi"}, wantB3Multi: true},
+		{name: "none", env: map[string]string{envTraceparent: testTraceparent}, args: []string{"--propagators", "none"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, k := range []string{envPropagators, envTraceparent, envW3CTraceparent, "B3", "X_B3_TRACEID", "X_B3_SPANID", "X_B3_SAMPLED"} {
+				t.Setenv(k, tt.env[k])
+			}
+			dir := t.TempDir()
+			logFile := filepath.Join(dir, "traces.jsonl")
+			outFile := filepath.Join(dir, "out")
+			cmd := NewCmdRun(printers.DefaultOSStreams())
+			args := append([]string{"--trace-log-file", logFile, "--span-delay", "0s"}, tt.args...)
+			script := `echo "$(printenv B3)|$B3|$(printenv X_B3_TRACEID)|$X_B3_SPANID|$(printenv X_B3_SAMPLED)|$(printenv TRACEPARENT)" > ` + outFile
+			cmd.SetArgs(append(args, "sh", "--", "-c", script))
+			if err := cmd.Execute(); err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+
+			span := readLoggedSpans(t, logFile)[0]
+			traceID, spanID := hex.EncodeToString(span.TraceId), hex.EncodeToString(span.SpanId)
+			if tt.wantTraceID != "" && traceID != tt.wantTraceID {
+				t.Errorf("trace id = %s, want %s", traceID, tt.wantTraceID)
+			}
+			if got := hex.EncodeToString(span.ParentSpanId); got != tt.wantParentID {
+				t.Errorf("parent span id = %q, want %q", got, tt.wantParentID)
+			}
+
+			b, err := os.ReadFile(outFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := strings.Split(strings.TrimSpace(string(b)), "|")
+			want := make([]string, 6)
+			if tt.wantB3 {
+				want[0] = traceID + "-" + spanID + "-1"
+				want[1] = want[0]
+			}
+			// variables of propagators which are not selected are cleared rather than inherited
+			if tt.wantB3Multi {
+				want[2], want[3], want[4] = traceID, spanID, "1"
+			}
+			if tt.wantTraceCtx {
+				want[5] = "00-" + traceID + "-" + spanID + "-01"
+			}
+			if strings.Join(got, "|") != strings.Join(want, "|") {
+				t.Errorf("child sees %q, want %q", strings.Join(got, "|"), strings.Join(want, "|"))
+			}
+		})
+	}
+}
+
+func TestRunOptions_Run_clearsInheritedB3Headers(t *testing.T) {
+	t.Setenv(envTraceparent, "")
+	t.Setenv("X_B3_TRACEID", "0af7651916cd43dd8448eb211c80319c")
+	t.Setenv("X_B3_SPANID", "b7ad6b7169203331")
+	t.Setenv("X_B3_PARENTSPANID", "00f067aa0ba902b7")
+	t.Setenv("X_B3_SAMPLED", "1")
+	t.Setenv("X_B3_FLAGS", "1")
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "traces.jsonl")
+	outFile := filepath.Join(dir, "out")
+	cmd := NewCmdRun(printers.DefaultOSStreams())
+	script := `echo "${X_B3_PARENTSPANID-unset}|${X_B3_FLAGS-unset}|$(printenv X_B3_SPANID)|$(env | grep -c '^X_B3_')" > ` + outFile
+	cmd.SetArgs([]string{"--trace-log-file", logFile, "--span-delay", "0s", "--propagators", "b3multi", "sh", "--", "-c", script})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	span := readLoggedSpans(t, logFile)[0]
+	b, err := os.ReadFile(outFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "unset|unset|" + hex.EncodeToString(span.SpanId) + "|3"
+	if got := strings.TrimSpace(string(b)); got != want {
+		t.Errorf("child sees %q, want %q", got, want)
+	}
+}
+
+// envTestRunsOpentracer makes the test binary behave as opentracer itself so that tests can
+// nest runs
+const envTestRunsOpentracer = "OPENTRACER_TEST_RUNS_OPENTRACER"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(envTestRunsOpentracer) != "" {
+		Execute()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func TestRunOptions_Run_nestedRunWithPropagators(t *testing.T) {
+	bin, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(envTestRunsOpentracer, "1")
+	tests := []struct {
+		name        string
+		propagators string
+	}{
+		{name: "default", propagators: "tracecontext,baggage"},
+		{name: "b3", propagators: "b3"},
+		{name: "none", propagators: "none"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(envPropagators, "")
+			t.Setenv(envTraceparent, "00-11111111111111111111111111111111-2222222222222222-01")
+			dir := t.TempDir()
+			outerLog, innerLog := filepath.Join(dir, "outer.jsonl"), filepath.Join(dir, "inner.jsonl")
+			cmd := NewCmdRun(printers.DefaultOSStreams())
+			cmd.SetArgs([]string{"--trace-log-file", outerLog, "--span-delay", "0s", "--propagators", tt.propagators, "--",
+				bin, "run", "--trace-log-file", innerLog, "--span-delay", "0s", "true"})
+			if err := cmd.Execute(); err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+
+			outer, inner := readLoggedSpans(t, outerLog)[0], readLoggedSpans(t, innerLog)[0]
+			if got, want := hex.EncodeToString(inner.TraceId), hex.EncodeToString(outer.TraceId); got != want {
+				t.Errorf("inner trace id = %s, want the outer span's %s", got, want)
+			}
+			if got, want := hex.EncodeToString(inner.ParentSpanId), hex.EncodeToString(outer.SpanId); got != want {
+				t.Errorf("inner parent span id = %s, want the outer span %s", got, want)
+			}
+		})
+	}
+}
+
+func TestNewPropagator(t *testing.T) {
+	tests := []struct {
+		name       string
+		names      []string
+		wantFields []string
+		wantErr    bool
+	}{
+		{name: "default", names: defaultPropagators, wantFields: []string{"baggage", "traceparent", "tracestate"}},
+		{name: "b3", names: []string{"b3", "b3multi"}, wantFields: []string{"b3", "x-b3-flags", "x-b3-sampled", "x-b3-spanid", "x-b3-traceid"}},
+		{name: "none", names: []string{"none"}},
+		{name: "unknown", names: []string{"xray"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := newPropagator(tt.names)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newPropagator() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			fields := p.Fields()
+			sort.Strings(fields)
+			if got := strings.Join(fields, ","); got != strings.Join(tt.wantFields, ",") {
+				t.Errorf("Fields() = %s, want %s", got, strings.Join(tt.wantFields, ","))
+			}
+		})
+	}
+}
+
+func TestRunOptions_Run_uberTraceID(t *testing.T) {
+	tests := []struct {
+		name         string
+		uberTraceID  string
+		args         []string
+		wantParentID string
+		// wantFlags is empty when UBER_TRACE_ID is not propagated
+		wantFlags string
+	}{
+		{name: "root", args: []string{"--propagators", "jaeger"}, wantParentID: "", wantFlags: "1"},
+		{name: "default ignores uber-trace-id", uberTraceID: "a3ce929d0e0e4736:00f067aa0ba902b7:0:1", wantParentID: ""},
+		{name: "none", uberTraceID: "a3ce929d0e0e4736:00f067aa0ba902b7:0:1", args: []string{"--propagators", "none"}, wantParentID: ""},
+		{name: "jaeger", uberTraceID: "a3ce929d0e0e4736:00f067aa0ba902b7:0:1", args: []string{"--propagators", "jaeger"}, wantParentID: "00f067aa0ba902b7", wantFlags: "1"},
+		{name: "jaeger debug", uberTraceID: "a3ce929d0e0e4736:00f067aa0ba902b7:0:2", args: []string{"--propagators", "tracecontext,jaeger"}, wantParentID: "00f067aa0ba902b7", wantFlags: "3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(envTraceparent, "")
+			t.Setenv("UBER_TRACE_ID", tt.uberTraceID)
+			dir := t.TempDir()
+			logFile := filepath.Join(dir, "traces.jsonl")
+			outFile := filepath.Join(dir, "out")
+			cmd := NewCmdRun(printers.DefaultOSStreams())
+			args := append([]string{"--trace-log-file", logFile, "--span-delay", "0s"}, tt.args...)
+			cmd.SetArgs(append(args, "sh", "--", "-c", `echo "$(printenv UBER_TRACE_ID)|${UBER_TRACE_ID}" > `+outFile))
+			if err := cmd.Execute(); err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+
+			span := readLoggedSpans(t, logFile)[0]
+			parentID := hex.EncodeToString(span.ParentSpanId)
+			if parentID != tt.wantParentID {
+				t.Errorf("parent span id = %q, want %q", parentID, tt.wantParentID)
+			}
+			if tt.wantParentID == "" {
+				parentID = "0"
+			}
+			traceID := strings.TrimPrefix(hex.EncodeToString(span.TraceId), "0000000000000000")
+			want := traceID + ":" + hex.EncodeToString(span.SpanId) + ":" + parentID + ":" + tt.wantFlags
+			if tt.wantFlags == "" {
+				// not propagated so the inherited value is cleared rather than passed on
+				want = ""
+			}
+
+			b, err := os.ReadFile(outFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := strings.Split(strings.TrimSpace(string(b)), "|")
+			if got[0] != want {
+				t.Errorf("child UBER_TRACE_ID = %q, want %q", got[0], want)
+			}
+			if got[1] != want {
+				t.Errorf("$UBER_TRACE_ID token = %q, want %q", got[1], want)
+			}
+		})
+	}
+}
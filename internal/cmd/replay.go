@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/davidalpert/go-printers/v1"
+	"github.com/davidalpert/opentracer/internal/otlpjson"
+	"github.com/spf13/cobra"
+	collectortracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+)
+
+// ReplayOptions is a struct to support the replay command
+type ReplayOptions struct {
+	*printers.PrinterOptions
+	OTLPExporterOptions
+	Debug         bool
+	TraceLogFiles []string
+}
+
+// ReplaySummary describes what the replay command sent
+type ReplaySummary struct {
+	Files   int `json:"files"`
+	Batches int `json:"batches"`
+	Spans   int `json:"spans"`
+}
+
+// String implements Stringer
+func (s ReplaySummary) String() string {
+	return fmt.Sprintf("replayed %d spans in %d batches from %d files\n", s.Spans, s.Batches, s.Files)
+}
+
+// NewReplayOptions returns initialized ReplayOptions
+func NewReplayOptions(s printers.IOStreams) *ReplayOptions {
+	return &ReplayOptions{
+		PrinterOptions: printers.NewPrinterOptions().WithStreams(s).WithDefaultOutput("text"),
+	}
+}
+
+// NewCmdReplay creates the replay command
+func NewCmdReplay(s printers.IOStreams) *cobra.Command {
+	o := NewReplayOptions(s)
+	var cmd = &cobra.Command{
+		Use:   "replay <trace-log-file> [more trace-log-files]",
+		Short: "Send the spans from a trace log file to an OTLP endpoint",
+		Long: `Re-export the spans recorded with --trace-log-file to an OpenTelemetry collector
+
+opentracer replay --trace-http-endpoint $OTELCOL_OTLP_HTTP_ENDPOINT /var/log/nightly-traces.jsonl
+
+Every span is sent with its original trace and span IDs, timestamps, attributes, events and status
+so replayed spans join the same traces as any spans which were exported live.
+
+The trace log must be written in the default otlp-json --trace-log-format.
+`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(cmd, args); err != nil {
+				return err
+			}
+			if err := o.Validate(); err != nil {
+				return err
+			}
+			if err := o.Run(); err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+
+	o.AddPrinterFlags(cmd.Flags())
+	o.AddOTLPExporterFlags(cmd.Flags())
+	cmd.Flags().BoolVar(&o.Debug, "debug", false, "debug")
+	return cmd
+}
+
+// Complete completes the ReplayOptions
+func (o *ReplayOptions) Complete(cmd *cobra.Command, args []string) error {
+	o.TraceLogFiles = args
+	return o.OTLPExporterOptions.Complete()
+}
+
+// Validate validates the ReplayOptions
+func (o *ReplayOptions) Validate() error {
+	if !o.hasEndpoint() {
+		return fmt.Errorf("at least one of --trace-http-endpoint, --trace-grpc-endpoint, or %s must be set", envOTLPEndpoint)
+	}
+	if err := o.OTLPExporterOptions.Validate(); err != nil {
+		return err
+	}
+	return o.PrinterOptions.Validate()
+}
+
+// Run executes the command
+func (o *ReplayOptions) Run() error {
+	if o.Debug {
+		o.printDebugExporters()
+	}
+
+	clients, err := o.newTraceClients(o.ExportTimeout)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	for _, client := range clients {
+		if err := client.Start(ctx); err != nil {
+			return err
+		}
+		defer client.Stop(ctx)
+	}
+
+	summary := ReplaySummary{}
+	for _, filename := range o.TraceLogFiles {
+		if err := replayTraceLogFile(ctx, filename, clients, &summary); err != nil {
+			return err
+		}
+		summary.Files++
+	}
+
+	return o.WriteOutput(summary)
+}
+
+// replayTraceLogFile uploads each batch in the given trace log to every client
+func replayTraceLogFile(ctx context.Context, filename string, clients []traceClient, summary *ReplaySummary) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	err = otlpjson.ReadTraceRequests(f, func(req *collectortracepb.ExportTraceServiceRequest) error {
+		for _, client := range clients {
+			if err := client.UploadTraces(ctx, req.ResourceSpans); err != nil {
+				return err
+			}
+		}
+		summary.Batches++
+		summary.Spans += countSpans(req)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+	return nil
+}
+
+// countSpans counts the spans in an ExportTraceServiceRequest
+func countSpans(req *collectortracepb.ExportTraceServiceRequest) int {
+	n := 0
+	for _, rs := range req.ResourceSpans {
+		for _, ils := range rs.InstrumentationLibrarySpans {
+			n += len(ils.Spans)
+		}
+	}
+	return n
+}
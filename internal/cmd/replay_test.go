@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/davidalpert/go-printers/v1"
+	"github.com/davidalpert/opentracer/internal/otlpjson"
+	collectortracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestReplayOptions_Run(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "traces.jsonl")
+	cmd := NewCmdRun(printers.DefaultOSStreams())
+	cmd.SetArgs([]string{"--trace-log-file", logFile, "--span-name", "Recorded", "--tag", "client:acme", "--span-delay", "0s", "false"})
+	if err := cmd.Execute(); err == nil {
+		t.Fatalf("Execute() expected the wrapped command to fail")
+	}
+
+	f, err := os.Open(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var recorded []*collectortracepb.ExportTraceServiceRequest
+	if err := otlpjson.ReadTraceRequests(f, func(req *collectortracepb.ExportTraceServiceRequest) error {
+		recorded = append(recorded, req)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	receiver, endpoint := startFakeGrpcTraceReceiver(t)
+	s, _, out, _ := printers.NewTestIOStreams()
+	cmd = NewCmdReplay(s)
+	cmd.SetArgs([]string{"--trace-grpc-endpoint", endpoint, logFile})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	if got, want := out.String(), "replayed 1 spans in 1 batches from 1 files\n"; got != want {
+		t.Errorf("replay output = %q, want %q", got, want)
+	}
+	receiver.mu.Lock()
+	defer receiver.mu.Unlock()
+	if len(receiver.spans) != 1 {
+		t.Fatalf("receiver got %d spans, want 1", len(receiver.spans))
+	}
+	want := recorded[0].ResourceSpans[0].InstrumentationLibrarySpans[0].Spans[0]
+	if got := receiver.spans[0]; !proto.Equal(got, want) {
+		t.Errorf("replayed span = %v, want %v", got, want)
+	}
+	if !bytes.Equal(receiver.spans[0].TraceId, want.TraceId) || want.Status.Code == 0 {
+		t.Errorf("replayed span lost its trace id or status: %v", receiver.spans[0])
+	}
+}
+
+func TestReplayOptions_Validate(t *testing.T) {
+	t.Setenv(envOTLPEndpoint, "")
+	t.Setenv(envOTLPTracesEndpoint, "")
+	o := NewReplayOptions(printers.DefaultOSStreams())
+	if err := o.Complete(nil, []string{"traces.jsonl"}); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if err := o.Validate(); err == nil {
+		t.Errorf("Validate() expected an error without an endpoint")
+	}
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/spf13/pflag"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// attribute keys describing retried runs
+const (
+	retryAttemptKey  = attribute.Key("opentracer.retry.attempt")
+	retryAttemptsKey = attribute.Key("opentracer.retry.attempts")
+	retryDelayKey    = attribute.Key("opentracer.retry.delay")
+)
+
+// retryEventName names the span event recorded before each retry
+const retryEventName = "retry"
+
+// RetryOptions configures re-running a command which fails
+type RetryOptions struct {
+	Retries          int
+	RetryDelay       time.Duration
+	RetryBackoff     float64
+	RetryOnExitCodes []int
+}
+
+// AddRetryFlags binds the retry flags to the given FlagSet
+func (o *RetryOptions) AddRetryFlags(flags *pflag.FlagSet) {
+	flags.IntVar(&o.Retries, "retries", 0, "re-run a failing command up to this many times; each attempt is traced as a child span")
+	flags.DurationVar(&o.RetryDelay, "retry-delay", time.Second, "how long to wait before the first retry (golang time.Duration)")
+	flags.Float64Var(&o.RetryBackoff, "retry-backoff", 2, "multiply --retry-delay by this after each retry (1 for a constant delay)")
+	flags.IntSliceVar(&o.RetryOnExitCodes, "retry-on-exit-codes", make([]int, 0), fmt.Sprintf("only retry these exit codes (default any non-zero code; a --timeout exits with %d)", timeoutExitCode))
+}
+
+// Validate validates the RetryOptions
+func (o *RetryOptions) Validate() error {
+	if o.Retries < 0 {
+		return fmt.Errorf("--retries must not be negative")
+	}
+	if o.RetryDelay < 0 {
+		return fmt.Errorf("--retry-delay must not be negative")
+	}
+	if o.RetryBackoff < 1 {
+		return fmt.Errorf("--retry-backoff must be at least 1")
+	}
+	return nil
+}
+
+// retryDelayFor returns how long to wait after the given (1-based) attempt fails
+func (o *RetryOptions) retryDelayFor(attempt int) time.Duration {
+	return time.Duration(float64(o.RetryDelay) * math.Pow(o.RetryBackoff, float64(attempt-1)))
+}
+
+// shouldRetry reports whether an attempt which failed with err is worth repeating; only
+// commands which ran and exited unsuccessfully (or timed out) are retried
+func (o *RetryOptions) shouldRetry(err error) bool {
+	var startErr *CommandStartError
+	if errors.As(err, &startErr) {
+		// a command which could not be found or executed won't be next time either
+		return false
+	}
+	code, ok := exitCodeOf(err)
+	if !ok || code == 0 {
+		return false
+	}
+	if len(o.RetryOnExitCodes) == 0 {
+		return true
+	}
+	for _, c := range o.RetryOnExitCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// exitCodeOf returns the code opentracer exits with for err (0 for nil) and whether err
+// describes how the wrapped command exited
+func exitCodeOf(err error) (int, bool) {
+	if err == nil {
+		return 0, true
+	}
+	var ec interface{ ExitCode() int }
+	if errors.As(err, &ec) {
+		return ec.ExitCode(), true
+	}
+	return 0, false
+}
+
+// waitForRetry waits for d unless one of forwardedSignals arrives first, which it returns
+func waitForRetry(d time.Duration) os.Signal {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, forwardedSignals...)
+	defer signal.Stop(signals)
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case sig := <-signals:
+		return sig
+	}
+}
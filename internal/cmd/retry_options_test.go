@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/davidalpert/go-printers/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func TestRetryOptions_retryDelayFor(t *testing.T) {
+	o := RetryOptions{RetryDelay: time.Second, RetryBackoff: 2}
+	for attempt, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second} {
+		if got := o.retryDelayFor(attempt); got != want {
+			t.Errorf("retryDelayFor(%d) = %s, want %s", attempt, got, want)
+		}
+	}
+}
+
+func TestRunOptions_Run_retries(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		failures     int
+		exitCode     int
+		wantAttempts int
+		wantCode     int
+	}{
+		{name: "succeeds after retries", args: []string{"--retries", "3"}, failures: 2, exitCode: 3, wantAttempts: 3, wantCode: 0},
+		{name: "gives up", args: []string{"--retries", "2"}, failures: 5, exitCode: 3, wantAttempts: 3, wantCode: 3},
+		{name: "exit code not retried", args: []string{"--retries", "2", "--retry-on-exit-codes", "4,5"}, failures: 5, exitCode: 3, wantAttempts: 1, wantCode: 3},
+		{name: "exit code retried", args: []string{"--retries", "2", "--retry-on-exit-codes", "3"}, failures: 1, exitCode: 3, wantAttempts: 2, wantCode: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			logFile := filepath.Join(dir, "traces.jsonl")
+			counter := filepath.Join(dir, "attempts")
+			// fail the first tt.failures attempts
+			script := "n=$(cat " + counter + " 2>/dev/null || echo 0); n=$((n+1)); echo $n > " + counter +
+				"; [ $n -gt " + strconv.Itoa(tt.failures) + " ] || exit " + strconv.Itoa(tt.exitCode)
+
+			cmd := NewCmdRun(printers.DefaultOSStreams())
+			args := append([]string{"--trace-log-file", logFile, "--span-delay", "0s", "--retry-delay", "1ms"}, tt.args...)
+			cmd.SetArgs(append(args, "sh", "--", "-c", script))
+			err := cmd.Execute()
+
+			gotCode, ok := exitCodeOf(err)
+			if !ok {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if gotCode != tt.wantCode {
+				t.Errorf("exit code = %d, want %d", gotCode, tt.wantCode)
+			}
+
+			spans := readLoggedSpans(t, logFile)
+			if len(spans) != tt.wantAttempts+1 {
+				t.Fatalf("logged %d spans, want %d attempts and the run span", len(spans), tt.wantAttempts)
+			}
+			var run *tracepb.Span
+			for _, s := range spans {
+				if s.Name == "Run" {
+					run = s
+				}
+			}
+			if run == nil {
+				t.Fatal("no Run span logged")
+			}
+			for _, s := range spans {
+				if s != run && string(s.ParentSpanId) != string(run.SpanId) {
+					t.Errorf("attempt span %q is not a child of the Run span", s.Name)
+				}
+			}
+			if got, _ := spanAttribute(run, string(retryAttemptsKey)); got != strconv.Itoa(tt.wantAttempts) {
+				t.Errorf("%s = %s, want %d", retryAttemptsKey, got, tt.wantAttempts)
+			}
+			if got, _ := spanAttribute(run, string(processExitCodeKey)); got != strconv.Itoa(tt.wantCode) {
+				t.Errorf("%s = %s, want %d", processExitCodeKey, got, tt.wantCode)
+			}
+			wantStatus := tracepb.Status_STATUS_CODE_UNSET
+			if tt.wantCode != 0 {
+				wantStatus = tracepb.Status_STATUS_CODE_ERROR
+			}
+			if run.Status.Code != wantStatus {
+				t.Errorf("status = %v, want %v", run.Status.Code, wantStatus)
+			}
+		})
+	}
+}
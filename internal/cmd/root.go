@@ -62,6 +62,8 @@ func NewRootCmd(s printers.IOStreams) *cobra.Command {
 	// when this action is called directly.
 	//bindLocalFlags(rootCmd)
 
+	rootCmd.AddCommand(NewCmdFlush(s))
+	rootCmd.AddCommand(NewCmdReplay(s))
 	rootCmd.AddCommand(NewCmdRun(s))
 	rootCmd.AddCommand(NewCmdVersion(s))
 
@@ -2,17 +2,23 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/davidalpert/go-printers/v1"
 	"github.com/davidalpert/opentracer/internal/datadog"
+	"github.com/davidalpert/opentracer/internal/otlpjson"
+	"github.com/davidalpert/opentracer/internal/redact"
+	"github.com/davidalpert/opentracer/internal/spool"
 	"github.com/davidalpert/opentracer/internal/types"
+	"github.com/davidalpert/opentracer/internal/utils"
 	"github.com/davidalpert/opentracer/internal/version"
 	"github.com/davidalpert/opentracer/internal/w3c"
 	"github.com/spf13/cobra"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
 	"go.opentelemetry.io/otel/codes"
-	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
 	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
@@ -30,7 +36,14 @@ import (
 // RunOptions is a struct to support version command
 type RunOptions struct {
 	*printers.PrinterOptions
+	OTLPExporterOptions
+	SpoolOptions
+	RetryOptions
+	CaptureOptions
+	LogsOptions
+	MetricsOptions
 	Command               string
+	Shell                 string
 	CommandArgs           []string
 	Debug                 bool
 	DeploymentEnvironment string
@@ -38,9 +51,25 @@ type RunOptions struct {
 	ServiceVersion        string
 	SpanName              string
 	SpanTagsRaw           []string
-	TraceOLTPHttpEndpoint string
 	TraceLogFile          string
+	TraceLogFormat        string
+	TraceLogAppend        bool
 	SpanDelay             time.Duration
+	OnExportError         string
+	SignalGrace           time.Duration
+	IncludeProcessAttrs   string
+	Redact                []string
+	redactor              *redact.Redactor
+	TraceStateRaw         []string
+	traceState            []w3c.TraceStateMember
+	BaggageRaw            []string
+	baggage               []baggage.Member
+	BaggageAttributes     []string
+	Propagators           []string
+	propagator            propagation.TextMapPropagator
+	Timeout               time.Duration
+	KillGrace             time.Duration
+	cancelledBy           os.Signal
 	VersionDetail         version.DetailStruct
 }
 
@@ -68,15 +97,38 @@ NOTE: flags before "--" are interpreted by opentracer; flags after "--" are pass
 
 Features:
 - opentracer performs token replacement on the command text before executing it;
+- --shell runs the arguments as one command line with $SHELL -c (or the shell given with --shell=/bin/bash) so a quoted pipeline, redirect or && chain is traced as one unit; tokens are replaced before the shell sees the line
+- opentracer exits with the wrapped command's exit code (128+N when the command is killed by signal N; like a shell, 127 when it is not found and 126 when it cannot be executed) and records it on the span as process.exit_code (and process.exit_signal)
+- the span records the command's user and system CPU time, max RSS, block I/O and context switches as process.* attributes (not on Windows)
+- the span also gets process.pid, process.parent_pid, process.executable.*, process.owner, process.working_directory and host.name; --include-process-attrs=full adds process.command_args with likely secrets redacted and none leaves them all out
+- bearer tokens, AWS keys, passwords in URLs and secret-looking flags or variables (--password=, API_KEY=) are replaced with [REDACTED] in --debug output, tag values, process attributes, captured output and exported log lines; add your own patterns with the repeatable --redact regex flag
+- SIGINT, SIGTERM, SIGHUP and SIGQUIT are forwarded to the wrapped command; an interrupted run gets a cancelled event and error status and its span is still flushed within --signal-grace
+- --timeout stops a command which runs too long: its process group gets SIGTERM, then SIGKILL after --kill-grace if anything in the group is still running, even when the command itself already exited (when stdin is a terminal the command stays in the terminal's process group so it can still read from it, and only the command itself is signalled; on Windows the command is killed outright); the span gets a timeout event and error status and opentracer exits with code 124
+- --retries re-runs a failing command (optionally only for --retry-on-exit-codes), waiting --retry-delay multiplied by --retry-backoff between attempts; each attempt is traced as a child span and the run span records the final outcome
+- --capture-output=stderr|both attaches the last --capture-tail-lines lines of the command's output (at most --capture-max-bytes per stream) and its total line and byte counts to the span as output events when the command fails, or on every run with --capture-when=always
+- --logs-endpoint exports each line the command prints to an OTLP/HTTP logs endpoint as a log record carrying the span's trace and span IDs and the same resource; stdout lines are INFO and stderr lines WARN (see --logs-stdout-severity and --logs-stderr-severity) and lines matching --logs-error-pattern are ERROR
+- --metrics exports an opentracer.run.duration histogram and an opentracer.run.count counter, labeled by span name, service, environment and exit code, to the same OTLP endpoints as the spans (over http at the /v1/metrics path of the trace endpoint's host); --metrics-file appends them as OTLP/JSON to a file (or stdout with -) for offline use
 - opentracer adds the same tokens as environment variables so any script run inside the command can also reference the trace context;
 - opentracer automatically creates nested spans; if you use opentracer to run a command or script which includes another call to opentracer the trace context propagates through environment variables
+- the parent trace context is read from the standard TRACEPARENT, TRACESTATE and BAGGAGE environment variables (set by CI tracing, otel-cli and opentracer itself), falling back to W3CTRACEPARENT when TRACEPARENT is not set; a malformed traceparent (bad version, length or hex, or an all-zero ID) is reported on stderr and the span starts a new trace; the command receives TRACEPARENT, TRACESTATE and BAGGAGE describing its span
+- vendor entries in an inherited TRACESTATE are validated (a malformed one is dropped with a warning) and kept on the span; the repeatable --tracestate key=value flag adds entries of your own and the command receives the result as W3CTRACESTATE and TRACESTATE
+- the repeatable --baggage key=value flag adds entries to the inherited BAGGAGE (replacing one with the same key) which the command receives as BAGGAGE; --baggage-attributes tenant,run copies those baggage keys onto the span as attributes, redacted like tags
+- --propagators (or OTEL_PROPAGATORS) picks how the trace context is read from the environment and passed to the command: tracecontext (TRACEPARENT, TRACESTATE), baggage (BAGGAGE), b3 (B3) and b3multi (X_B3_TRACEID, X_B3_SPANID, X_B3_SAMPLED; an inherited X_B3_FLAGS or X_B3_PARENTSPANID is not passed on) for Zipkin-instrumented tools, jaeger (UBER_TRACE_ID, honoring its debug flag) or none; the default is tracecontext,baggage, the last propagator which finds a parent wins and each variable written is also a token (e.g. $B3); inherited variables of propagators which are not selected are cleared rather than passed on so the command never sees a stale parent (a nested opentracer then falls back to W3CTRACEPARENT)
 - override the deployment.environment value
   - for example: --deployment-environment dev or -e dev
 - add arbitrary tags with the format --tag key:value and opentracer adds them to the wrapping span as string values;
   - for example: --tag client:my_company
 - add typed spans by optionally specifying one of the supported types --tag key:value:type
   - for example: --tag is_registered:true:bool
-- you can send traces to any OpenTelemetry collector configured with an OTLP HTTP endpoint using --trace-http-endpoint or to an OpenTelemetry log file using --trace-log-file
+- you can send traces to any OpenTelemetry collector configured with an OTLP HTTP endpoint using --trace-http-endpoint, an OTLP gRPC endpoint using --trace-grpc-endpoint, or to an OpenTelemetry log file using --trace-log-file
+  - --trace-log-format selects otlp-json (the default; one OTLP ExportTraceServiceRequest JSON document per line, as written by the collector's file exporter), pretty or compact
+  - --trace-log-append appends to the log instead of truncating it so nested opentracer calls can share one file
+- with --spool-dir batches which fail to export are written to disk instead of being lost; each run resends any spooled batches in the background, each only to the endpoint which failed to export it, and opentracer flush resends them on demand (--spool-max-attempts and --spool-max-age bound how long they are kept); each export gets half of --export-timeout so that a batch for an unreachable endpoint is spooled before the final flush gives up
+- a telemetry outage never crashes opentracer: --export-timeout bounds each export and the final flush, and --on-export-error chooses whether export failures are ignored, reported on stderr (warn, the default) or make opentracer exit non-zero (fail); the wrapped command's own failure always takes precedence
+- when neither --trace-http-endpoint nor --trace-grpc-endpoint is given opentracer honors the standard OTEL_EXPORTER_OTLP_ENDPOINT, OTEL_EXPORTER_OTLP_PROTOCOL (http/protobuf or grpc) and OTEL_EXPORTER_OTLP_HEADERS environment variables (and their _TRACES_ variants); --trace-log-file is written in addition to that endpoint
+- add headers to every OTLP export (e.g. an API key) with the repeatable --trace-header key=value flag or keep secrets out of argv with --trace-header-file (one key=value per line); header values are redacted in --debug output
+- talk TLS to a collector with a private CA using --trace-ca-cert, present a client certificate for mutual TLS with --trace-client-cert and --trace-client-key, or skip certificate verification with --trace-insecure-skip-verify; setting any of these enables TLS even when the endpoint does not start with https://
+- OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES are added to the span's resource; the --service, --service-version and --deployment-environment flags take precedence when set
 
 Supported replacement tokens
 
@@ -85,25 +137,30 @@ Supported replacement tokens
 | TRACE_ID       | 4bf92f3577b34da6a3ce929d0e0e4736                        | An OpenTelemetry-formatted 128-bit hexidecimal value                                   |
 | SPAN_ID        | 00f067aa0ba902b7                                        | An OpenTelemetry-formatted 64-bit hexidecimal value                                    |
 | W3CTRACEPARENT | 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01 | Trace context formatted for W3C standard: https://w3c.github.io/trace-context/         |
+| W3CTRACESTATE  | rojo=00f067aa0ba902b7,congo=t61rcWkgMzE                 | Vendor-specific trace state formatted for W3C standard (empty when there is none)      |
+| UBER_TRACE_ID  | a3ce929d0e0e4736:00f067aa0ba902b7:05e3ac9a4f6e3b90:1    | With --propagators jaeger: uber-trace-id {trace-id}:{span-id}:{parent-span-id}:{flags} |
+| BAGGAGE        | tenant=acme,run=1234                                    | W3C baggage: inherited BAGGAGE plus --baggage entries                                  |
+| B3             | 4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-1     | With --propagators b3: Zipkin B3 single header                                         |
+| X_B3_TRACEID   | 4bf92f3577b34da6a3ce929d0e0e4736                        | With --propagators b3multi: X-B3-TraceId (also X_B3_SPANID, X_B3_SAMPLED)              |
 | DD_TRACE_ID    | 9856658736241331422                                     | TRACE_ID as 64-bit unsigned integer matching Datadog's X-DATADOG-TRACE-ID HTTP header  | 
 | DD_SPAN_ID     | 1930319880373503199                                     | SPAN_ID  as 64-bit unsigned integer matching Datadog's X-DATADOG-PARENT-ID HTTP header | 
 
 To send the trace context downstream to an OpenTelemetry-instrumented service set the traceparent HTTP header which encodes the trace ID and parent span ID:
 
 ---
-./opentracer --tag c:false -e dev --trace-http-endpoint localhost:9003 run '/usr/bin/curl -kv -H traceparent:$W3CTRACEPARENT https://your.opentelemetry-instrumented.service.com/info'
+./opentracer --tag c:false -e dev --trace-http-endpoint localhost:9003 run --shell '/usr/bin/curl -kv -H traceparent:$W3CTRACEPARENT https://your.opentelemetry-instrumented.service.com/info'
 ---
 
 If you want more fine-grained control over the traceparent header which conforms to the W3C [trace-context](https://w3c.github.io/trace-context/) spec use the individual TRACE_ID and SPAN_ID variables:
 
 ---
-./opentracer --tag c:false -e dev --trace-http-endpoint localhost:9003 run '/usr/bin/curl -kv -H traceparent:00-$TRACE_ID-$SPAN_ID-00 https://your.opentelemetry-instrumented.service.com/info'
+./opentracer --tag c:false -e dev --trace-http-endpoint localhost:9003 run --shell '/usr/bin/curl -kv -H traceparent:00-$TRACE_ID-$SPAN_ID-00 https://your.opentelemetry-instrumented.service.com/info'
 ---
 
 Datadog uses a proprietary format for trace and parent IDs. If you want to propagate trace context to a datadog-instrumented service appropriately formatted DD_TRACE_ID and DD_SPAN_ID tokens also available:
 
 ---
-./opentracer --tag c:134:int -e dev --trace-http-endpoint localhost:9003 run '/usr/bin/curl -kv -H X-DATADOG-TRACE-ID:$DD_TRACE_ID -H X-DATADOG-PARENT-ID:$DD_SPAN_ID https://your.datadog-instrumented.service.com/info'
+./opentracer --tag c:134:int -e dev --trace-http-endpoint localhost:9003 run --shell '/usr/bin/curl -kv -H X-DATADOG-TRACE-ID:$DD_TRACE_ID -H X-DATADOG-PARENT-ID:$DD_SPAN_ID https://your.datadog-instrumented.service.com/info'
 ---
 
 `,
@@ -124,15 +181,34 @@ Datadog uses a proprietary format for trace and parent IDs. If you want to propa
 	}
 
 	o.AddPrinterFlags(cmd.Flags())
+	o.AddOTLPExporterFlags(cmd.Flags())
+	o.AddSpoolFlags(cmd.Flags())
+	o.AddRetryFlags(cmd.Flags())
+	o.AddCaptureFlags(cmd.Flags())
+	o.AddLogsFlags(cmd.Flags())
+	o.AddMetricsFlags(cmd.Flags())
+	cmd.Flags().DurationVar(&o.Timeout, "timeout", 0, "stop the command if it runs longer than this; 0 waits forever (golang time.Duration)")
+	cmd.Flags().DurationVar(&o.KillGrace, "kill-grace", 10*time.Second, "after --timeout sends SIGTERM, how long to wait before sending SIGKILL (golang time.Duration)")
+	cmd.Flags().StringVar(&o.Shell, "shell", "", fmt.Sprintf("run the arguments as one command line with this shell's -c so pipes, redirects and && work; --shell alone uses %s (or %s)", shellFromEnvironment, defaultShell))
+	cmd.Flags().Lookup("shell").NoOptDefVal = shellFromEnvironment
+	cmd.Flags().StringArrayVar(&o.TraceStateRaw, "tracestate", make([]string, 0), "add a key=value entry to the W3C tracestate passed to the span and the command; repeatable, the first entry ends up left-most")
+	cmd.Flags().StringSliceVar(&o.Propagators, "propagators", defaultPropagators, fmt.Sprintf("how the trace context is read from the environment and passed to the command; a list of: %s (defaults to %s when set)", strings.Join(propagatorNames, ", "), envPropagators))
+	cmd.Flags().StringArrayVar(&o.BaggageRaw, "baggage", make([]string, 0), "add a key=value entry to the W3C baggage passed to the command; repeatable, replaces an inherited entry with the same key")
+	cmd.Flags().StringSliceVar(&o.BaggageAttributes, "baggage-attributes", make([]string, 0), "copy these baggage keys (inherited or from --baggage) onto the span as attributes")
+	cmd.Flags().StringArrayVar(&o.Redact, "redact", make([]string, 0), "also redact text matching this regular expression (only its first capture group, if it has one) from debug output, tags, process attributes and captured output; repeatable")
+	cmd.Flags().StringVar(&o.IncludeProcessAttrs, "include-process-attrs", processAttrsBasic, fmt.Sprintf("which process.* attributes to add to the span; one of: %s ('full' adds the command arguments with likely secrets redacted)", strings.Join(processAttrsLevels, ", ")))
+	cmd.Flags().DurationVar(&o.SignalGrace, "signal-grace", 5*time.Second, "when opentracer is interrupted, how long to wait for the final flush after the command exits (golang time.Duration)")
+	cmd.Flags().StringVar(&o.OnExportError, "on-export-error", onExportErrorWarn, fmt.Sprintf("what to do when spans cannot be exported; one of: %s ('fail' only changes the exit code when the command itself succeeded)", strings.Join(onExportErrorPolicies, ", ")))
 	cmd.Flags().StringVarP(&o.DeploymentEnvironment, "deployment-environment", "e", "prd", "deployment environment")
-	cmd.Flags().StringVar(&o.TraceOLTPHttpEndpoint, "trace-http-endpoint", "", "sent traces over http to this endpoint")
-	cmd.Flags().StringVar(&o.TraceLogFile, "trace-log-file", "", "log traces to this file")
+	cmd.Flags().StringVar(&o.TraceLogFile, "trace-log-file", "", "log traces to this file (in addition to any OTEL_EXPORTER_OTLP_* endpoint)")
+	cmd.Flags().StringVar(&o.TraceLogFormat, "trace-log-format", traceLogFormatOTLPJSON, fmt.Sprintf("format for --trace-log-file; one of: %s", strings.Join(traceLogFormats, ", ")))
+	cmd.Flags().BoolVar(&o.TraceLogAppend, "trace-log-append", false, "append to --trace-log-file instead of truncating it")
 	cmd.Flags().StringSliceVar(&o.SpanTagsRaw, "tag", make([]string, 0), "tags in the format key:val[:type]")
 	cmd.Flags().DurationVar(&o.SpanDelay, "span-delay", 100*time.Millisecond, "how long to wait after the command completes before completing the span (golang time.Duration)")
 	cmd.Flags().StringVar(&o.SpanName, "span-name", "Run", "name for this span")
 	cmd.Flags().StringVar(&o.ServiceName, "service", o.VersionDetail.AppName, "value for this span's service tag")
 	cmd.Flags().StringVar(&o.ServiceVersion, "service-version", o.VersionDetail.Version, "value for this span's service version tag")
-	cmd.Flags().BoolVar(&o.Debug, "debug", false, "debug :WARNING: this can dump secrets to the command line")
+	cmd.Flags().BoolVar(&o.Debug, "debug", false, "debug :WARNING: known secret patterns and --redact matches are masked but other secrets can still be printed")
 	return cmd
 }
 
@@ -140,7 +216,14 @@ Datadog uses a proprietary format for trace and parent IDs. If you want to propa
 func (o *RunOptions) Complete(cmd *cobra.Command, args []string) error {
 	o.Command = args[0]
 	o.CommandArgs = args[1:]
-	return nil
+	if o.Shell != "" {
+		o.Command, o.CommandArgs = shellCommand(o.Shell, args)
+	}
+	// --trace-log-file adds to the environment endpoint rather than replacing it
+	if err := o.OTLPExporterOptions.Complete(); err != nil {
+		return err
+	}
+	return o.completeFromEnvironment(cmd.Flags())
 }
 
 // Validate validates the RunOptions
@@ -151,36 +234,113 @@ func (o *RunOptions) Validate() error {
 	if o.SpanName == "" {
 		return fmt.Errorf("span-name is required")
 	}
-	if o.TraceLogFile == "" && o.TraceOLTPHttpEndpoint == "" {
-		return fmt.Errorf("at least one of --trace-log-file and --trace-http-endpoint must be set")
+	if o.TraceLogFile == "" && !o.hasEndpoint() {
+		return fmt.Errorf("at least one of --trace-log-file, --trace-http-endpoint, --trace-grpc-endpoint, or %s must be set", envOTLPEndpoint)
+	}
+	if o.Timeout < 0 {
+		return fmt.Errorf("--timeout must not be negative")
+	}
+	if o.KillGrace < 0 {
+		return fmt.Errorf("--kill-grace must not be negative")
+	}
+	if !utils.StringInSlice(onExportErrorPolicies, o.OnExportError) {
+		return fmt.Errorf("--on-export-error must be one of: %s", strings.Join(onExportErrorPolicies, ", "))
+	}
+	r, err := redact.New(o.Redact...)
+	if err != nil {
+		return fmt.Errorf("--redact: %w", err)
+	}
+	o.redactor = r
+	if o.traceState, err = parseTraceStateEntries(o.TraceStateRaw); err != nil {
+		return err
+	}
+	if o.baggage, err = parseBaggageEntries(o.BaggageRaw); err != nil {
+		return err
+	}
+	if o.propagator, err = newPropagator(o.Propagators); err != nil {
+		return err
+	}
+	if !utils.StringInSlice(processAttrsLevels, o.IncludeProcessAttrs) {
+		return fmt.Errorf("--include-process-attrs must be one of: %s", strings.Join(processAttrsLevels, ", "))
+	}
+	if !utils.StringInSlice(traceLogFormats, o.TraceLogFormat) {
+		return fmt.Errorf("--trace-log-format must be one of: %s", strings.Join(traceLogFormats, ", "))
+	}
+	if err := o.OTLPExporterOptions.Validate(); err != nil {
+		return err
+	}
+	if err := o.SpoolOptions.Validate(); err != nil {
+		return err
+	}
+	if err := o.RetryOptions.Validate(); err != nil {
+		return err
+	}
+	if err := o.CaptureOptions.Validate(); err != nil {
+		return err
+	}
+	if err := o.LogsOptions.Validate(); err != nil {
+		return err
+	}
+	if o.Metrics && !o.hasEndpoint() {
+		return fmt.Errorf("--metrics requires --trace-http-endpoint, --trace-grpc-endpoint, or %s (use --metrics-file to record metrics offline)", envOTLPEndpoint)
 	}
 	return o.PrinterOptions.Validate()
 }
 
+// shellFromEnvironment is the value of a bare --shell flag; it selects $SHELL
+const shellFromEnvironment = "$SHELL"
+
+// defaultShell is used by a bare --shell when $SHELL is not set
+const defaultShell = "/bin/sh"
+
+// supported --trace-log-format values
+const (
+	traceLogFormatOTLPJSON = "otlp-json"
+	traceLogFormatPretty   = "pretty"
+	traceLogFormatCompact  = "compact"
+)
+
+var traceLogFormats = []string{traceLogFormatOTLPJSON, traceLogFormatPretty, traceLogFormatCompact}
+
 // newConsoleExporter returns a console exporter.
-func newConsoleExporter(w io.Writer) (sdktrace.SpanExporter, error) {
-	return stdouttrace.New(
+func newConsoleExporter(w io.Writer, prettyPrint bool) (sdktrace.SpanExporter, error) {
+	opts := []stdouttrace.Option{
 		stdouttrace.WithWriter(w),
+	}
+	if prettyPrint {
 		// Use human readable output.
-		stdouttrace.WithPrettyPrint(),
-		// Do not print timestamps for the demo.
-		stdouttrace.WithoutTimestamps(),
-	)
+		opts = append(opts, stdouttrace.WithPrettyPrint())
+	}
+	return stdouttrace.New(opts...)
 }
 
-func newFileExporter(filename string) (*sdktrace.SpanExporter, func(), error) {
+func newFileExporter(filename string, format string, appendToFile bool) (*sdktrace.SpanExporter, func(), error) {
 	cleanupFN := func() {}
 	if filename == "" {
 		return nil, cleanupFN, fmt.Errorf("cannot export to an empty filename")
 	}
-	// Write telemetry data to a file.
-	f, err := os.Create(filename)
+	// Write telemetry data to a file; appending lets nested opentracer calls share one log.
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if appendToFile {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	f, err := os.OpenFile(filename, flags, 0644)
 	if err != nil {
 		return nil, cleanupFN, err
 	}
 	cleanupFN = func() { f.Close() }
 
-	exp, err := newConsoleExporter(f)
+	var exp sdktrace.SpanExporter
+	switch format {
+	case traceLogFormatOTLPJSON:
+		exp, err = otlptrace.New(context.TODO(), otlpjson.NewTraceClient(f))
+	case traceLogFormatPretty:
+		exp, err = newConsoleExporter(f, true)
+	case traceLogFormatCompact:
+		exp, err = newConsoleExporter(f, false)
+	default:
+		err = fmt.Errorf("unsupported trace log format '%s'", format)
+	}
 	if err != nil {
 		return nil, cleanupFN, err
 	}
@@ -203,13 +363,16 @@ func (o *RunOptions) newTracerResource() *resource.Resource {
 }
 
 // Run executes the command
-func (o *RunOptions) Run() error {
+func (o *RunOptions) Run() (err error) {
 	traceProviderOptions := []sdktrace.TracerProviderOption{
 		sdktrace.WithResource(o.newTracerResource()),
 	}
+	if len(o.traceState) > 0 {
+		traceProviderOptions = append(traceProviderOptions, sdktrace.WithSampler(newTraceStateSampler(o.traceState)))
+	}
 
 	if o.TraceLogFile != "" {
-		exp, cleanupFN, err := newFileExporter(o.TraceLogFile)
+		exp, cleanupFN, err := newFileExporter(o.TraceLogFile, o.TraceLogFormat, o.TraceLogAppend)
 		if err != nil {
 			return err
 		}
@@ -217,53 +380,118 @@ func (o *RunOptions) Run() error {
 		traceProviderOptions = append(traceProviderOptions, sdktrace.WithBatcher(*exp))
 	}
 
-	if o.TraceOLTPHttpEndpoint != "" {
-		exp, err := otlptracehttp.New(context.TODO(),
-			buildHttpTraceExporterSpanOptionsForEndpoint(o.TraceOLTPHttpEndpoint)...,
-		)
+	clients, err := o.newTraceClients(o.spoolExportTimeout(o.ExportTimeout))
+	if err != nil {
+		return err
+	}
+	spooled := make([]*spool.Client, 0, len(clients))
+	for _, c := range clients {
+		client := c.Client
+		if o.SpoolDir != "" {
+			s, err := o.newSpool(c.Protocol)
+			if err != nil {
+				return err
+			}
+			sc := spool.NewClient(client, s)
+			spooled = append(spooled, sc)
+			client = sc
+		}
+		exp, err := otlptrace.New(context.TODO(), client)
 		if err != nil {
 			return err
 		}
 		traceProviderOptions = append(traceProviderOptions, sdktrace.WithBatcher(exp))
 	}
 
+	if o.Debug {
+		fmt.Printf("------------------------------------------------------------------------------------\n")
+		if o.SpoolDir != "" {
+			fmt.Printf("spooling failed exports to: %s\n", o.SpoolDir)
+		}
+		if o.TraceLogFile != "" {
+			fmt.Printf("exporting to trace log file: %s\n", o.TraceLogFile)
+		}
+		o.printDebugExporters()
+		if o.LogsEndpoint != "" {
+			fmt.Printf("exporting command output to logs endpoint: %s\n", o.LogsEndpoint)
+		}
+	}
+
+	exportErrors := &exportErrorRecorder{}
+	otel.SetErrorHandler(exportErrors)
 	tp := sdktrace.NewTracerProvider(traceProviderOptions...)
 	defer func() {
-		if err := tp.Shutdown(context.Background()); err != nil {
-			panic(err)
-		}
+		err = o.handleExportError(err, "spans", o.shutdownTracerProvider(tp, exportErrors))
+		o.printDebugSpooled(spooled)
 	}()
 	otel.SetTracerProvider(tp)
 
-	parentContext := context.Background()
-	if os.Getenv("W3CTRACEPARENT") != "" {
-		if o.Debug {
-			fmt.Printf("------------------------------------------------------------------------------------\n")
-			fmt.Printf("found trace parent: %s\n", os.Getenv("W3CTRACEPARENT"))
+	if o.SpoolDir != "" && len(clients) > 0 {
+		// the (unwrapped) clients were started by otlptrace.New; deferred after the
+		// tracer provider shutdown so the flush is waited for before the clients stop
+		defer o.flushSpoolsInBackground(clients)()
+	}
+
+	if o.LogsEndpoint != "" {
+		if o.logs, err = o.newLogsExporter(); err != nil {
+			return err
 		}
-		parentContext = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}).Extract(parentContext, propagation.MapCarrier{
-			w3c.TraceparentHeader: os.Getenv("W3CTRACEPARENT"),
-		})
+		defer func() {
+			err = o.handleExportError(err, "logs", o.shutdownLogs(o.shutdownTimeout()))
+		}()
 	}
+
+	parentContext := o.extractParentContext()
 	ctx, span := otel.Tracer(o.VersionDetail.AppName,
 		trace.WithInstrumentationVersion(o.VersionDetail.Version),
 	).Start(parentContext, o.SpanName)
 	defer span.End()
 	cmdCtx := trace.ContextWithSpan(context.TODO(), span)
 
+	span.SetAttributes(baggageAttributes(ctx, o.BaggageAttributes, o.redactor)...)
 	for _, s := range o.SpanTagsRaw {
 		if a, err := rawTagToTypedAttribute(cmdCtx, s); err != nil {
 			return err
 		} else {
+			if a.Value.Type() == attribute.STRING {
+				a = a.Key.String(o.redactor.String(a.Value.AsString()))
+			}
 			span.SetAttributes(a)
 		}
 	}
 
-	o.Command = injectTraceAndSpanID(cmdCtx, o.Command)
+	start := time.Now()
+	if o.Retries > 0 {
+		err = o.runWithRetries(ctx, span)
+	} else {
+		err = o.runCommand(o.newCommand(ctx), span)
+	}
+	err = o.handleExportError(err, "metrics", o.exportRunMetrics(start, time.Now(), err))
+
+	time.Sleep(o.SpanDelay)
+
+	return err
+}
+
+// shellCommand returns the command which runs args as a single command line with shell
+func shellCommand(shell string, args []string) (string, []string) {
+	if shell == shellFromEnvironment {
+		shell = os.Getenv("SHELL")
+		if shell == "" {
+			shell = defaultShell
+		}
+	}
+	return shell, []string{"-c", strings.Join(args, " ")}
+}
+
+// newCommand builds the wrapped command, injecting the trace context of the span in ctx
+func (o *RunOptions) newCommand(ctx context.Context) *exec.Cmd {
+	name := injectPropagationTokens(ctx, o.propagator, injectTraceAndSpanID(ctx, o.Command))
+	args := make([]string, len(o.CommandArgs))
 	for i, s := range o.CommandArgs {
-		o.CommandArgs[i] = injectTraceAndSpanID(cmdCtx, s)
+		args[i] = injectPropagationTokens(ctx, o.propagator, injectTraceAndSpanID(ctx, s))
 	}
-	c := exec.CommandContext(ctx, o.Command, o.CommandArgs...)
+	c := exec.CommandContext(ctx, name, args...)
 	c.Stdout = os.Stdout
 	c.Stdin = os.Stdin
 	c.Stderr = os.Stderr
@@ -273,37 +501,161 @@ func (o *RunOptions) Run() error {
 		c.Env[i] = e
 	}
 	c.Env = appendTraceAndSpanIDToEnv(ctx, c.Env)
+	c.Env = appendPropagationToEnv(ctx, o.propagator, c.Env)
+	if o.Timeout > 0 {
+		startInProcessGroup(c)
+	}
 
 	if o.Debug {
 		fmt.Printf("------------------------------------------------------------------------------------\n")
-		fmt.Printf("opentracer running: %s %s\n", c.Path, strings.Join(c.Args[1:], " "))
+		fmt.Printf("opentracer running: %s\n", o.redactor.String(strings.Join(append([]string{c.Path}, o.redactor.Args(c.Args[1:])...), " ")))
 		fmt.Printf("------------------------------------------------------------------------------------\n")
 	}
-	err := c.Run()
+	return c
+}
+
+// runWithRetries runs the command up to --retries more times until it succeeds, tracing
+// each attempt as a child of span and recording the final outcome on span
+func (o *RunOptions) runWithRetries(ctx context.Context, span trace.Span) error {
+	tracer := span.TracerProvider().Tracer(o.VersionDetail.AppName,
+		trace.WithInstrumentationVersion(o.VersionDetail.Version),
+	)
+
+	var err error
+	attempt := 1
+	for ; ; attempt++ {
+		attemptCtx, attemptSpan := tracer.Start(ctx, fmt.Sprintf("%s attempt %d", o.SpanName, attempt),
+			trace.WithAttributes(retryAttemptKey.Int(attempt)),
+		)
+		err = o.runCommand(o.newCommand(attemptCtx), attemptSpan)
+		attemptSpan.End()
+		if attempt > o.Retries || o.cancelledBy != nil || !o.shouldRetry(err) {
+			break
+		}
+
+		delay := o.retryDelayFor(attempt)
+		span.AddEvent(retryEventName, trace.WithAttributes(
+			retryAttemptKey.Int(attempt+1),
+			retryDelayKey.String(delay.String()),
+			attribute.String("error", err.Error()),
+		))
+		if sig := waitForRetry(delay); sig != nil {
+			span.AddEvent(cancelledEventName, trace.WithAttributes(attribute.String("signal", osSignalName(sig))))
+			o.cancelledBy = sig
+			break
+		}
+	}
+
+	span.SetAttributes(retryAttemptsKey.Int(attempt))
+	if code, ok := exitCodeOf(err); ok {
+		span.SetAttributes(processExitCodeKey.Int(code))
+		var exitErr *CommandExitError
+		if errors.As(err, &exitErr) && exitErr.Signal != "" {
+			span.SetAttributes(processExitSignalKey.String(exitErr.Signal))
+		}
+	}
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
-	} else if c.ProcessState != nil && c.ProcessState.ExitCode() != 0 {
-		err = fmt.Errorf("run command exited with error: %d", c.ProcessState.ExitCode())
-		span.RecordError(err)
-		span.SetStatus(codes.Error, err.Error())
 	}
+	if o.cancelledBy != nil {
+		span.SetStatus(codes.Error, fmt.Sprintf("cancelled by %s", osSignalName(o.cancelledBy)))
+	}
+	return err
+}
 
-	time.Sleep(o.SpanDelay)
+// runCommand runs c, forwarding signals to it and stopping it after --timeout, and records
+// how it exited, the resources it used and (with --capture-output) what it printed on span
+func (o *RunOptions) runCommand(c *exec.Cmd, span trace.Span) error {
+	capture := o.captureOutput(c)
+	logs := o.exportOutputLogs(c, span, o.redactor)
+	if err := c.Start(); err != nil {
+		startErr := &CommandStartError{Err: err}
+		// opentracer exits silently with the shell's exit code so say why here, like a shell would
+		fmt.Fprintf(o.ErrOut, "opentracer: %s\n", startErr)
+		span.SetAttributes(processExitCodeKey.Int(startErr.ExitCode()))
+		span.RecordError(startErr)
+		span.SetStatus(codes.Error, startErr.Error())
+		return startErr
+	}
+	span.SetAttributes(processAttributes(c, o.IncludeProcessAttrs, o.redactor)...)
 
+	forwarder := forwardSignals(c, span)
+	var watchdog *timeoutWatchdog
+	if o.Timeout > 0 {
+		watchdog = watchTimeout(c, span, o.Timeout, o.KillGrace)
+	}
+	err := c.Wait()
+	logs.close()
+	timedOut := watchdog != nil && watchdog.stop()
+	cancelledBy := forwarder.stop()
+
+	if c.ProcessState != nil {
+		// the command ran; report how it exited rather than how exec describes it
+		code, signal := exitStatus(c.ProcessState)
+		span.SetAttributes(exitStatusAttributes(code, signal)...)
+		span.SetAttributes(rusageAttributes(c.ProcessState)...)
+		err = nil
+		if code != 0 {
+			err = &CommandExitError{Code: code, Signal: signal}
+		}
+	}
+	if timedOut {
+		err = &CommandTimeoutError{Timeout: o.Timeout}
+	}
+	capture.record(span, err != nil, o.redactor)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	if cancelledBy != nil {
+		o.cancelledBy = cancelledBy
+		span.SetStatus(codes.Error, fmt.Sprintf("cancelled by %s", osSignalName(cancelledBy)))
+	}
 	return err
 }
 
-func buildHttpTraceExporterSpanOptionsForEndpoint(endpoint string) []otlptracehttp.Option {
-	opts := []otlptracehttp.Option{
-		otlptracehttp.WithEndpoint(endpoint),
+// printDebugSpooled reports how many batches failed to export and were spooled
+func (o *RunOptions) printDebugSpooled(clients []*spool.Client) {
+	if !o.Debug || len(clients) == 0 {
+		return
 	}
-
-	if !strings.HasPrefix(endpoint, "https://") {
-		opts = append(opts, otlptracehttp.WithInsecure())
+	n := 0
+	for _, c := range clients {
+		n += c.Spooled()
 	}
+	fmt.Printf("------------------------------------------------------------------------------------\n")
+	fmt.Printf("spooled %d failed batches to: %s\n", n, o.SpoolDir)
+}
 
-	return opts
+// flushSpoolsInBackground opportunistically resends batches spooled by earlier runs while
+// the command runs and returns a func which waits for it; failures are not errors since the
+// batches stay in the spool for the next run or 'opentracer flush'
+func (o *RunOptions) flushSpoolsInBackground(clients []traceClient) (wait func()) {
+	ctx, cancel := context.WithTimeout(context.Background(), spoolFlushTimeout)
+	done := make(chan struct{})
+	var result spool.FlushResult
+	var err error
+	go func() {
+		defer close(done)
+		result, err = o.flushSpools(ctx, clients)
+	}()
+
+	return func() {
+		if o.cancelledBy != nil {
+			// don't hold up a cancelled run; what's left stays spooled
+			cancel()
+		}
+		<-done
+		cancel()
+		if o.Debug {
+			fmt.Printf("------------------------------------------------------------------------------------\n")
+			fmt.Printf("flushed spool %s: %s", o.SpoolDir, result)
+			if err != nil {
+				fmt.Printf("flush stopped early: %s\n", err)
+			}
+		}
+	}
 }
 
 func injectTraceAndSpanID(ctx context.Context, s string) string {
@@ -336,6 +688,10 @@ func injectTraceAndSpanID(ctx context.Context, s string) string {
 	s = strings.Replace(s, "$W3CTRACEPARENT", traceparentValue, -1)
 	s = strings.Replace(s, "${W3CTRACEPARENT}", traceparentValue, -1)
 
+	tracestateValue := w3c.NewTraceStateFromSpanContext(spanCtx).String()
+	s = strings.Replace(s, "$W3CTRACESTATE", tracestateValue, -1)
+	s = strings.Replace(s, "${W3CTRACESTATE}", tracestateValue, -1)
+
 	return s
 }
 
@@ -345,6 +701,7 @@ func appendTraceAndSpanIDToEnv(ctx context.Context, ss []string) []string {
 	ss = append(ss, injectTraceAndSpanID(ctx, "DD_TRACE_ID=$DD_TRACE_ID"))
 	ss = append(ss, injectTraceAndSpanID(ctx, "DD_SPAN_ID=$DD_SPAN_ID"))
 	ss = append(ss, injectTraceAndSpanID(ctx, "W3CTRACEPARENT=$W3CTRACEPARENT"))
+	ss = append(ss, injectTraceAndSpanID(ctx, "W3CTRACESTATE=$W3CTRACESTATE"))
 	ss = append(ss, fmt.Sprintf("OPENTRACER_VERSION=%s", version.Detail.Version))
 	return ss
 }
@@ -2,8 +2,19 @@ package cmd
 
 import (
 	"context"
+	"github.com/davidalpert/go-printers/v1"
+	"github.com/davidalpert/opentracer/internal/otlpjson"
+	"github.com/spf13/cobra"
 	"go.opentelemetry.io/otel/attribute"
+	collectortracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc"
+	"net"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -63,3 +74,324 @@ func Test_rawTagToTypedAttribute(t *testing.T) {
 		})
 	}
 }
+
+// fakeTraceReceiver is an in-process OTLP trace collector which records the spans it receives
+type fakeTraceReceiver struct {
+	collectortracepb.UnimplementedTraceServiceServer
+	mu    sync.Mutex
+	spans []*tracepb.Span
+	// err (when set) rejects every export
+	err error
+}
+
+func (r *fakeTraceReceiver) setErr(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.err = err
+}
+
+func (r *fakeTraceReceiver) Export(_ context.Context, req *collectortracepb.ExportTraceServiceRequest) (*collectortracepb.ExportTraceServiceResponse, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return nil, r.err
+	}
+	for _, rs := range req.ResourceSpans {
+		for _, ils := range rs.InstrumentationLibrarySpans {
+			r.spans = append(r.spans, ils.Spans...)
+		}
+	}
+	return &collectortracepb.ExportTraceServiceResponse{}, nil
+}
+
+func (r *fakeTraceReceiver) spanNames() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	names := make([]string, 0, len(r.spans))
+	for _, s := range r.spans {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+func startFakeGrpcTraceReceiver(t *testing.T) (*fakeTraceReceiver, string) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	r := &fakeTraceReceiver{}
+	srv := grpc.NewServer()
+	collectortracepb.RegisterTraceServiceServer(srv, r)
+	go srv.Serve(l)
+	t.Cleanup(srv.Stop)
+	return r, l.Addr().String()
+}
+
+func TestRunOptions_Run_exportsToGrpcEndpoint(t *testing.T) {
+	receiver, endpoint := startFakeGrpcTraceReceiver(t)
+
+	cmd := NewCmdRun(printers.DefaultOSStreams())
+	cmd.SetArgs([]string{"--trace-grpc-endpoint", endpoint, "--span-name", "GrpcRun", "--span-delay", "0s", "true"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	if got := receiver.spanNames(); !reflect.DeepEqual(got, []string{"GrpcRun"}) {
+		t.Errorf("receiver got spans %v, want [GrpcRun]", got)
+	}
+}
+
+func Test_buildGrpcTraceExporterSpanOptionsForEndpoint(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		wantLen  int
+	}{
+		{endpoint: "localhost:4317", wantLen: 2},
+		{endpoint: "http://localhost:4317", wantLen: 2},
+		{endpoint: "https://collector.example.com:4317", wantLen: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.endpoint, func(t *testing.T) {
+			if got := buildGrpcTraceExporterSpanOptionsForEndpoint(tt.endpoint, nil, nil); len(got) != tt.wantLen {
+				t.Errorf("buildGrpcTraceExporterSpanOptionsForEndpoint() got %d options, want %d", len(got), tt.wantLen)
+			}
+		})
+	}
+}
+
+func TestRunOptions_completeFromEnvironment(t *testing.T) {
+	tests := []struct {
+		name            string
+		env             map[string]string
+		args            []string
+		wantHttp        string
+		wantGrpc        string
+		wantHeaders     map[string]string
+		wantService     string
+		wantEnvironment string
+		wantErr         bool
+	}{
+		{
+			name:            "generic http endpoint is a base URL",
+			env:             map[string]string{envOTLPEndpoint: "http://collector:4318"},
+			wantHttp:        "http://collector:4318/v1/traces",
+			wantHeaders:     map[string]string{},
+			wantService:     "opentracer",
+			wantEnvironment: "prd",
+		},
+		{
+			name:            "signal-specific endpoint is used as-is",
+			env:             map[string]string{envOTLPEndpoint: "http://collector:4318", envOTLPTracesEndpoint: "http://traces:4318/custom"},
+			wantHttp:        "http://traces:4318/custom",
+			wantHeaders:     map[string]string{},
+			wantService:     "opentracer",
+			wantEnvironment: "prd",
+		},
+		{
+			name:            "grpc protocol",
+			env:             map[string]string{envOTLPEndpoint: "http://collector:4317", envOTLPProtocol: "grpc"},
+			wantGrpc:        "http://collector:4317",
+			wantHeaders:     map[string]string{},
+			wantService:     "opentracer",
+			wantEnvironment: "prd",
+		},
+		{
+			name:    "unsupported protocol",
+			env:     map[string]string{envOTLPEndpoint: "http://collector:4318", envOTLPProtocol: "http/json"},
+			wantErr: true,
+		},
+		{
+			name:            "flags take precedence",
+			env:             map[string]string{envOTLPEndpoint: "http://collector:4318", "OTEL_SERVICE_NAME": "from-env", "OTEL_RESOURCE_ATTRIBUTES": "deployment.environment=stg"},
+			args:            []string{"--trace-grpc-endpoint", "localhost:4317", "--service", "from-flag", "-e", "dev"},
+			wantGrpc:        "localhost:4317",
+			wantHeaders:     map[string]string{},
+			wantService:     "from-flag",
+			wantEnvironment: "dev",
+		},
+		{
+			name:            "trace log file keeps the environment endpoint",
+			env:             map[string]string{envOTLPEndpoint: "http://collector:4318"},
+			args:            []string{"--trace-log-file", "traces.jsonl"},
+			wantHttp:        "http://collector:4318/v1/traces",
+			wantHeaders:     map[string]string{},
+			wantService:     "opentracer",
+			wantEnvironment: "prd",
+		},
+		{
+			name:            "service and resource attributes",
+			env:             map[string]string{envOTLPEndpoint: "http://collector:4318", "OTEL_SERVICE_NAME": "from-env", "OTEL_RESOURCE_ATTRIBUTES": "service.name=ignored,deployment.environment=stg"},
+			wantHttp:        "http://collector:4318/v1/traces",
+			wantHeaders:     map[string]string{},
+			wantService:     "from-env",
+			wantEnvironment: "stg",
+		},
+		{
+			name:            "headers",
+			env:             map[string]string{envOTLPEndpoint: "http://collector:4318", envOTLPHeaders: "api-key=secret%3D,a=b", envOTLPTracesHeaders: "a=c"},
+			wantHttp:        "http://collector:4318/v1/traces",
+			wantHeaders:     map[string]string{"api-key": "secret=", "a": "c"},
+			wantService:     "opentracer",
+			wantEnvironment: "prd",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, k := range []string{envOTLPEndpoint, envOTLPTracesEndpoint, envOTLPProtocol, envOTLPTracesProtocol, envOTLPHeaders, envOTLPTracesHeaders, "OTEL_SERVICE_NAME", "OTEL_RESOURCE_ATTRIBUTES"} {
+				t.Setenv(k, tt.env[k])
+			}
+			o := NewRunOptions(printers.DefaultOSStreams())
+			cmd := &cobra.Command{}
+			cmd.Flags().StringVar(&o.TraceOLTPGrpcEndpoint, "trace-grpc-endpoint", "", "")
+			cmd.Flags().StringVar(&o.TraceLogFile, "trace-log-file", "", "")
+			cmd.Flags().StringVar(&o.ServiceName, "service", "opentracer", "")
+			cmd.Flags().StringVar(&o.ServiceVersion, "service-version", "", "")
+			cmd.Flags().StringVarP(&o.DeploymentEnvironment, "deployment-environment", "e", "prd", "")
+			if err := cmd.Flags().Parse(tt.args); err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			err := o.Complete(cmd, []string{"true"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Complete() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if o.TraceOLTPHttpEndpoint != tt.wantHttp || o.TraceOLTPGrpcEndpoint != tt.wantGrpc {
+				t.Errorf("Complete() endpoints = (%q, %q), want (%q, %q)", o.TraceOLTPHttpEndpoint, o.TraceOLTPGrpcEndpoint, tt.wantHttp, tt.wantGrpc)
+			}
+			if !reflect.DeepEqual(o.TraceHeaders, tt.wantHeaders) {
+				t.Errorf("Complete() headers = %v, want %v", o.TraceHeaders, tt.wantHeaders)
+			}
+			if o.ServiceName != tt.wantService || o.DeploymentEnvironment != tt.wantEnvironment {
+				t.Errorf("Complete() service/environment = (%q, %q), want (%q, %q)", o.ServiceName, o.DeploymentEnvironment, tt.wantService, tt.wantEnvironment)
+			}
+		})
+	}
+}
+
+func TestOTLPExporterOptions_completeTraceHeaders(t *testing.T) {
+	headerFile := filepath.Join(t.TempDir(), "headers")
+	if err := os.WriteFile(headerFile, []byte("# api credentials\nx-api-key=from-file\n\nx-tenant = acme\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		envHeaders  map[string]string
+		headerFile  string
+		rawHeaders  []string
+		wantHeaders map[string]string
+		wantErr     bool
+	}{
+		{
+			name:        "flags",
+			rawHeaders:  []string{"authorization=Bearer abc=", "x-api-key=123"},
+			wantHeaders: map[string]string{"authorization": "Bearer abc=", "x-api-key": "123"},
+		},
+		{
+			name:        "file",
+			headerFile:  headerFile,
+			wantHeaders: map[string]string{"x-api-key": "from-file", "x-tenant": "acme"},
+		},
+		{
+			name:        "flags override file override environment",
+			envHeaders:  map[string]string{"x-api-key": "from-env", "x-env": "env"},
+			headerFile:  headerFile,
+			rawHeaders:  []string{"x-tenant=from-flag"},
+			wantHeaders: map[string]string{"x-api-key": "from-file", "x-env": "env", "x-tenant": "from-flag"},
+		},
+		{
+			name:       "missing value",
+			rawHeaders: []string{"x-api-key"},
+			wantErr:    true,
+		},
+		{
+			name:       "missing file",
+			headerFile: filepath.Join(t.TempDir(), "missing"),
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &OTLPExporterOptions{TraceHeaders: tt.envHeaders, TraceHeaderFile: tt.headerFile, TraceHeadersRaw: tt.rawHeaders}
+			err := o.completeTraceHeaders()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("completeTraceHeaders() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(o.TraceHeaders, tt.wantHeaders) {
+				t.Errorf("completeTraceHeaders() got = %v, want %v", o.TraceHeaders, tt.wantHeaders)
+			}
+		})
+	}
+}
+
+func Test_redactedHeaderLines(t *testing.T) {
+	got := redactedHeaderLines(map[string]string{"x-api-key": "secret", "authorization": "Bearer secret"})
+	want := []string{"authorization=[REDACTED]", "x-api-key=[REDACTED]"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("redactedHeaderLines() got = %v, want %v", got, want)
+	}
+}
+
+func TestRunOptions_Run_appendsToTraceLogFile(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "traces.jsonl")
+	for i := 0; i < 2; i++ {
+		cmd := NewCmdRun(printers.DefaultOSStreams())
+		cmd.SetArgs([]string{"--trace-log-file", logFile, "--trace-log-append", "--span-delay", "0s", "true"})
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+	}
+
+	b, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("trace log has %d lines, want 2:\n%s", len(lines), b)
+	}
+	for _, line := range lines {
+		req := &collectortracepb.ExportTraceServiceRequest{}
+		if err := otlpjson.Unmarshal([]byte(line), req); err != nil {
+			t.Fatalf("otlpjson.Unmarshal() error = %v", err)
+		}
+		if span := req.ResourceSpans[0].InstrumentationLibrarySpans[0].Spans[0]; span.StartTimeUnixNano == 0 {
+			t.Errorf("span %s has no start timestamp", span.Name)
+		}
+	}
+}
+
+func TestRunOptions_Run_redactsBeforeExport(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "traces.jsonl")
+	cmd := NewCmdRun(printers.DefaultOSStreams())
+	cmd.SetArgs([]string{"--trace-log-file", logFile, "--span-delay", "0s", "--capture-output", "stderr", "--redact", `acct-\d+`,
+		"--tag", "auth:Bearer abc123", "--tag", "account:acct-42",
+		"sh", "--", "-c", "echo 'charging acct-42 with DB_PASSWORD=hunter2' >&2; exit 1"})
+	if _, ok := exitCodeOf(cmd.Execute()); !ok {
+		t.Fatal("Execute() did not run the command")
+	}
+
+	span := readLoggedSpans(t, logFile)[0]
+	for key, want := range map[string]string{"auth": "Bearer " + redactedValue, "account": redactedValue} {
+		if got, _ := spanAttribute(span, key); got != want {
+			t.Errorf("tag %s = %q, want %q", key, got, want)
+		}
+	}
+	var tail string
+	for _, e := range span.Events {
+		if e.Name == outputEventName {
+			for _, kv := range e.Attributes {
+				if kv.Key == string(outputTailKey) {
+					tail = kv.Value.GetStringValue()
+				}
+			}
+		}
+	}
+	if want := "charging " + redactedValue + " with DB_PASSWORD=" + redactedValue; tail != want {
+		t.Errorf("captured stderr = %q, want %q", tail, want)
+	}
+}
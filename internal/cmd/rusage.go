@@ -0,0 +1,41 @@
+//go:build unix
+
+package cmd
+
+import (
+	"os"
+	"syscall"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// attribute keys describing the resources used by the wrapped command (and any descendants
+// it waited for), named after the process.* semantic conventions
+const (
+	processCPUUserTimeKey                = attribute.Key("process.cpu.user_time")
+	processCPUSystemTimeKey              = attribute.Key("process.cpu.system_time")
+	processMemoryMaxRSSKey               = attribute.Key("process.memory.max_rss")
+	processDiskReadBlocksKey             = attribute.Key("process.disk.io.read_blocks")
+	processDiskWriteBlocksKey            = attribute.Key("process.disk.io.write_blocks")
+	processContextSwitchesVoluntaryKey   = attribute.Key("process.context_switches.voluntary")
+	processContextSwitchesInvoluntaryKey = attribute.Key("process.context_switches.involuntary")
+)
+
+// rusageAttributes describes the resource usage of a finished process as span attributes;
+// CPU times are in seconds and max RSS in bytes
+func rusageAttributes(state *os.ProcessState) []attribute.KeyValue {
+	ru, ok := state.SysUsage().(*syscall.Rusage)
+	if !ok || ru == nil {
+		return nil
+	}
+	return []attribute.KeyValue{
+		processCPUUserTimeKey.Float64(time.Duration(ru.Utime.Nano()).Seconds()),
+		processCPUSystemTimeKey.Float64(time.Duration(ru.Stime.Nano()).Seconds()),
+		processMemoryMaxRSSKey.Int64(int64(ru.Maxrss) * maxRSSUnit),
+		processDiskReadBlocksKey.Int64(int64(ru.Inblock)),
+		processDiskWriteBlocksKey.Int64(int64(ru.Oublock)),
+		processContextSwitchesVoluntaryKey.Int64(int64(ru.Nvcsw)),
+		processContextSwitchesInvoluntaryKey.Int64(int64(ru.Nivcsw)),
+	}
+}
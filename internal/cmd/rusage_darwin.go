@@ -0,0 +1,4 @@
+package cmd
+
+// maxRSSUnit converts rusage's ru_maxrss to bytes; darwin already reports bytes
+const maxRSSUnit = 1
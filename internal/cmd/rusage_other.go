@@ -0,0 +1,6 @@
+//go:build unix && !darwin
+
+package cmd
+
+// maxRSSUnit converts rusage's ru_maxrss to bytes; linux and the BSDs report kilobytes
+const maxRSSUnit = 1024
@@ -0,0 +1,39 @@
+//go:build unix
+
+package cmd
+
+import (
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/davidalpert/go-printers/v1"
+)
+
+func TestRunOptions_Run_recordsResourceUsage(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "traces.jsonl")
+	cmd := NewCmdRun(printers.DefaultOSStreams())
+	cmd.SetArgs([]string{"--trace-log-file", logFile, "--span-delay", "0s", "sh", "--", "-c", "i=0; while [ $i -lt 1000 ]; do i=$((i+1)); done"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	span := readLoggedSpans(t, logFile)[0]
+	for _, key := range []string{
+		string(processCPUUserTimeKey),
+		string(processCPUSystemTimeKey),
+		string(processMemoryMaxRSSKey),
+		string(processDiskReadBlocksKey),
+		string(processDiskWriteBlocksKey),
+		string(processContextSwitchesVoluntaryKey),
+		string(processContextSwitchesInvoluntaryKey),
+	} {
+		if _, ok := spanAttribute(span, key); !ok {
+			t.Errorf("span has no %s attribute", key)
+		}
+	}
+	rss, _ := spanAttribute(span, string(processMemoryMaxRSSKey))
+	if n, err := strconv.ParseInt(rss, 10, 64); err != nil || n < 1024 {
+		t.Errorf("%s = %s, want a size in bytes", processMemoryMaxRSSKey, rss)
+	}
+}
@@ -0,0 +1,12 @@
+package cmd
+
+import (
+	"os"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// rusageAttributes records nothing on windows, which has no rusage for a finished process
+func rusageAttributes(state *os.ProcessState) []attribute.KeyValue {
+	return nil
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/davidalpert/go-printers/v1"
+)
+
+func Test_shellCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		shell    string
+		envShell string
+		wantName string
+	}{
+		{name: "explicit shell", shell: "/bin/bash", envShell: "/bin/zsh", wantName: "/bin/bash"},
+		{name: "shell from environment", shell: shellFromEnvironment, envShell: "/bin/zsh", wantName: "/bin/zsh"},
+		{name: "default shell", shell: shellFromEnvironment, wantName: defaultShell},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SHELL", tt.envShell)
+			name, args := shellCommand(tt.shell, []string{"echo a | tr a b", "&&", "true"})
+			if name != tt.wantName {
+				t.Errorf("shellCommand() name = %q, want %q", name, tt.wantName)
+			}
+			if want := []string{"-c", "echo a | tr a b && true"}; !reflect.DeepEqual(args, want) {
+				t.Errorf("shellCommand() args = %v, want %v", args, want)
+			}
+		})
+	}
+}
+
+func TestRunOptions_Run_shell(t *testing.T) {
+	t.Setenv("SHELL", "/bin/sh")
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "traces.jsonl")
+	outFile := filepath.Join(dir, "out")
+	cmd := NewCmdRun(printers.DefaultOSStreams())
+	cmd.SetArgs([]string{"--trace-log-file", logFile, "--span-delay", "0s", "--shell", "echo a | tr a b > " + outFile + " && echo ${SPAN_ID} >> " + outFile})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	b, err := os.ReadFile(outFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	span := readLoggedSpans(t, logFile)[0]
+	if want := "b\n" + hex.EncodeToString(span.SpanId) + "\n"; string(b) != want {
+		t.Errorf("pipeline wrote %q, want %q", strings.TrimSpace(string(b)), strings.TrimSpace(want))
+	}
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// forwardedSignals are relayed to the wrapped command instead of terminating opentracer so
+// that the span still ends and is flushed when a job is cancelled
+var forwardedSignals = []os.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT}
+
+// cancelledEventName names the span event recorded for each forwarded signal
+const cancelledEventName = "cancelled"
+
+// signalForwarder relays signals received by opentracer to a running command
+type signalForwarder struct {
+	signals chan os.Signal
+	done    chan struct{}
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	first   os.Signal
+}
+
+// forwardSignals starts relaying forwardedSignals to the started command c and records
+// each one as a "cancelled" event on span
+func forwardSignals(c *exec.Cmd, span trace.Span) *signalForwarder {
+	f := &signalForwarder{
+		signals: make(chan os.Signal, len(forwardedSignals)),
+		done:    make(chan struct{}),
+	}
+	signal.Notify(f.signals, forwardedSignals...)
+
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		for {
+			select {
+			case sig := <-f.signals:
+				f.mu.Lock()
+				if f.first == nil {
+					f.first = sig
+				}
+				f.mu.Unlock()
+				span.AddEvent(cancelledEventName, trace.WithAttributes(attribute.String("signal", osSignalName(sig))))
+				signalCommand(c, sig)
+			case <-f.done:
+				return
+			}
+		}
+	}()
+	return f
+}
+
+// stop stops relaying signals and returns the first signal received, if any
+func (f *signalForwarder) stop() os.Signal {
+	signal.Stop(f.signals)
+	close(f.done)
+	f.wg.Wait()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.first
+}
+
+// osSignalName names an os.Signal
+func osSignalName(sig os.Signal) string {
+	if s, ok := sig.(syscall.Signal); ok {
+		return signalName(s)
+	}
+	return sig.String()
+}
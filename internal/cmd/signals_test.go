@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"context"
+	"os/exec"
+	"syscall"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func Test_forwardSignals(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	_, span := tp.Tracer("test").Start(context.Background(), "Run")
+
+	c := exec.Command("sleep", "5")
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+	f := forwardSignals(c, span)
+	// deliver the signal as signal.Notify would without signalling the test process itself
+	f.signals <- syscall.SIGTERM
+
+	c.Wait()
+	if got := f.stop(); got != syscall.SIGTERM {
+		t.Errorf("stop() = %v, want SIGTERM", got)
+	}
+	if code, signal := exitStatus(c.ProcessState); code != 143 || signal != "SIGTERM" {
+		t.Errorf("exitStatus() = (%d, %s), want (143, SIGTERM)", code, signal)
+	}
+
+	span.End()
+	events := recorder.Ended()[0].Events()
+	if len(events) != 1 || events[0].Name != cancelledEventName || events[0].Attributes[0].Value.AsString() != "SIGTERM" {
+		t.Errorf("span events = %v, want one %s event for SIGTERM", events, cancelledEventName)
+	}
+}
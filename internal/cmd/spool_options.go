@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/davidalpert/opentracer/internal/spool"
+	"github.com/spf13/pflag"
+)
+
+// spoolFlushTimeout bounds how long 'run' spends resending spooled batches in the background
+const spoolFlushTimeout = 5 * time.Second
+
+// SpoolOptions configures the offline spool for batches which could not be exported
+type SpoolOptions struct {
+	SpoolDir         string
+	SpoolMaxAttempts int
+	SpoolMaxAge      time.Duration
+}
+
+// AddSpoolFlags binds the spool flags to the given FlagSet
+func (o *SpoolOptions) AddSpoolFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&o.SpoolDir, "spool-dir", "", "persist batches which fail to export to this directory and resend them later; each export then gets half of --export-timeout so it fails, and is spooled, before the final flush gives up")
+	flags.IntVar(&o.SpoolMaxAttempts, "spool-max-attempts", 5, "drop a spooled batch after this many failed resends (0 for no limit)")
+	flags.DurationVar(&o.SpoolMaxAge, "spool-max-age", 24*time.Hour, "drop spooled batches older than this without sending them (0 for no limit)")
+}
+
+// Validate validates the SpoolOptions
+func (o *SpoolOptions) Validate() error {
+	if o.SpoolMaxAttempts < 0 {
+		return fmt.Errorf("--spool-max-attempts must not be negative")
+	}
+	if o.SpoolMaxAge < 0 {
+		return fmt.Errorf("--spool-max-age must not be negative")
+	}
+	return nil
+}
+
+// spoolExportTimeout returns the budget for each export when spooling: half of exportTimeout,
+// so that an export to an unreachable endpoint fails, and is spooled, well before the final
+// flush (bounded by exportTimeout as a whole) gives up on it
+func (o *SpoolOptions) spoolExportTimeout(exportTimeout time.Duration) time.Duration {
+	if o.SpoolDir == "" {
+		return exportTimeout
+	}
+	return exportTimeout / 2
+}
+
+// newSpool opens the spool subdirectory for the endpoint with the given protocol; each
+// endpoint has its own so a batch is only resent to the endpoint which failed to export it
+func (o *SpoolOptions) newSpool(protocol string) (*spool.Spool, error) {
+	return spool.New(filepath.Join(o.SpoolDir, protocol), o.SpoolMaxAttempts, o.SpoolMaxAge)
+}
+
+// flushSpools resends each endpoint's spooled batches to that endpoint only; an endpoint
+// which stops early does not keep the others from flushing and the first error is returned
+func (o *SpoolOptions) flushSpools(ctx context.Context, clients []traceClient) (spool.FlushResult, error) {
+	total := spool.FlushResult{}
+	var firstErr error
+	for _, c := range clients {
+		s, err := o.newSpool(c.Protocol)
+		if err == nil {
+			var result spool.FlushResult
+			result, err = s.Flush(ctx, c.UploadTraces)
+			total = total.Add(result)
+		}
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("%s: %w", c.Protocol, err)
+		}
+	}
+	return total, firstErr
+}
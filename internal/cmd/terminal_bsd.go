@@ -0,0 +1,8 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+
+package cmd
+
+import "golang.org/x/sys/unix"
+
+// ioctlReadTermios reads a terminal's attributes
+const ioctlReadTermios = unix.TIOCGETA
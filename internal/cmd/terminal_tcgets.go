@@ -0,0 +1,8 @@
+//go:build aix || linux || solaris
+
+package cmd
+
+import "golang.org/x/sys/unix"
+
+// ioctlReadTermios reads a terminal's attributes
+const ioctlReadTermios = unix.TCGETS
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"fmt"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// timeoutEventName names the span event recorded each time a timed out command is signalled
+const timeoutEventName = "timeout"
+
+// timeoutExitCode is the code opentracer exits with when --timeout expires; it matches
+// coreutils timeout(1) so callers can tell a hung command from one that failed on its own
+const timeoutExitCode = 124
+
+// CommandTimeoutError reports that the wrapped command was stopped because it ran longer
+// than --timeout
+type CommandTimeoutError struct {
+	Timeout time.Duration
+}
+
+// Error implements error
+func (e *CommandTimeoutError) Error() string {
+	return fmt.Sprintf("run command timed out after %s", e.Timeout)
+}
+
+// ExitCode returns the code opentracer should exit with
+func (e *CommandTimeoutError) ExitCode() int {
+	return timeoutExitCode
+}
+
+// killGracePollInterval is how often the watchdog checks whether a command which was sent
+// SIGTERM has stopped, so that it does not wait out --kill-grace for nothing
+const killGracePollInterval = 50 * time.Millisecond
+
+// timeoutWatchdog stops a command which runs longer than its timeout
+type timeoutWatchdog struct {
+	done     chan struct{}
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+	timedOut bool
+}
+
+// watchTimeout sends SIGTERM to the process group of the started command c once timeout
+// expires and SIGKILL if it (or anything left in its process group after it exited) is still
+// running killGrace later, recording each signal as a "timeout" event on span
+func watchTimeout(c *exec.Cmd, span trace.Span, timeout, killGrace time.Duration) *timeoutWatchdog {
+	w := &timeoutWatchdog{done: make(chan struct{})}
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-w.done:
+			return
+		}
+
+		w.mu.Lock()
+		w.timedOut = true
+		w.mu.Unlock()
+		w.signal(c, span, timeout, syscall.SIGTERM)
+
+		grace := time.NewTimer(killGrace)
+		defer grace.Stop()
+		poll := time.NewTicker(killGracePollInterval)
+		defer poll.Stop()
+		done, exited := w.done, false
+		for {
+			select {
+			case <-grace.C:
+				if commandRunning(c, exited) {
+					w.signal(c, span, timeout, syscall.SIGKILL)
+				}
+				return
+			case <-done:
+				// the command exited but grandchildren in its group may still ignore SIGTERM
+				done, exited = nil, true
+			case <-poll.C:
+			}
+			if !commandRunning(c, exited) {
+				return
+			}
+		}
+	}()
+	return w
+}
+
+func (w *timeoutWatchdog) signal(c *exec.Cmd, span trace.Span, timeout time.Duration, sig syscall.Signal) {
+	span.AddEvent(timeoutEventName, trace.WithAttributes(
+		attribute.String("timeout", timeout.String()),
+		attribute.String("signal", signalName(sig)),
+	))
+	signalCommand(c, sig)
+}
+
+// stop stops watching the command and reports whether it timed out; after a timeout it
+// waits until the command's process group has stopped or been sent SIGKILL
+func (w *timeoutWatchdog) stop() bool {
+	close(w.done)
+	w.wg.Wait()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.timedOut
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/davidalpert/go-printers/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func TestRunOptions_Run_timeout(t *testing.T) {
+	tests := []struct {
+		name        string
+		script      string
+		wantSignals []string
+	}{
+		{name: "stops on SIGTERM", script: "sleep 5", wantSignals: []string{"SIGTERM"}},
+		{name: "escalates to SIGKILL", script: "trap '' TERM; sleep 5", wantSignals: []string{"SIGTERM", "SIGKILL"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logFile := filepath.Join(t.TempDir(), "traces.jsonl")
+			cmd := NewCmdRun(printers.DefaultOSStreams())
+			cmd.SetArgs([]string{"--trace-log-file", logFile, "--span-delay", "0s", "--timeout", "100ms", "--kill-grace", "200ms", "sh", "--", "-c", tt.script})
+			err := cmd.Execute()
+
+			var timeoutErr *CommandTimeoutError
+			if !errors.As(err, &timeoutErr) {
+				t.Fatalf("Execute() error = %v, want a CommandTimeoutError", err)
+			}
+			if timeoutErr.ExitCode() != timeoutExitCode {
+				t.Errorf("exit code = %d, want %d", timeoutErr.ExitCode(), timeoutExitCode)
+			}
+
+			span := readLoggedSpans(t, logFile)[0]
+			if span.Status.Code != tracepb.Status_STATUS_CODE_ERROR {
+				t.Errorf("status = %v, want error", span.Status)
+			}
+			gotSignals := make([]string, 0)
+			for _, e := range span.Events {
+				if e.Name == timeoutEventName {
+					for _, kv := range e.Attributes {
+						if kv.Key == "signal" {
+							gotSignals = append(gotSignals, kv.Value.GetStringValue())
+						}
+					}
+				}
+			}
+			if len(gotSignals) != len(tt.wantSignals) {
+				t.Fatalf("timeout events signalled %v, want %v", gotSignals, tt.wantSignals)
+			}
+			for i := range gotSignals {
+				if gotSignals[i] != tt.wantSignals[i] {
+					t.Errorf("timeout events signalled %v, want %v", gotSignals, tt.wantSignals)
+				}
+			}
+		})
+	}
+}
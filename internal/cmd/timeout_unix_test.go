@@ -0,0 +1,117 @@
+//go:build unix
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/davidalpert/go-printers/v1"
+)
+
+// processRunning reports whether pid is alive and not a zombie waiting to be reaped
+func processRunning(pid int) bool {
+	if err := syscall.Kill(pid, 0); err != nil {
+		return false
+	}
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return !os.IsNotExist(err)
+	}
+	// the state follows the parenthesised command name
+	fields := strings.Fields(string(stat[strings.LastIndexByte(string(stat), ')')+1:]))
+	return len(fields) == 0 || fields[0] != "Z"
+}
+
+func TestRunOptions_Run_timeoutKillsProcessGroup(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+	}{
+		{name: "leader ignores SIGTERM", script: `trap '' TERM; sh -c 'echo $$ > %s; exec sleep 5' & wait`},
+		{name: "leader exits on SIGTERM", script: `sh -c 'trap "" TERM; echo $$ > %s; exec sleep 5' & wait`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			logFile := filepath.Join(dir, "traces.jsonl")
+			pidFile := filepath.Join(dir, "pid")
+			cmd := NewCmdRun(printers.DefaultOSStreams())
+			cmd.SetArgs([]string{"--trace-log-file", logFile, "--span-delay", "0s", "--timeout", "200ms", "--kill-grace", "200ms", "sh", "--", "-c", fmt.Sprintf(tt.script, pidFile)})
+			start := time.Now()
+			err := cmd.Execute()
+
+			var timeoutErr *CommandTimeoutError
+			if !errors.As(err, &timeoutErr) {
+				t.Fatalf("Execute() error = %v, want a CommandTimeoutError", err)
+			}
+			if elapsed := time.Since(start); elapsed > 3*time.Second {
+				t.Errorf("Execute() took %s, want the grandchild killed after --kill-grace", elapsed)
+			}
+			b, err := os.ReadFile(pidFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pid, err := strconv.Atoi(strings.TrimSpace(string(b)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			deadline := time.Now().Add(time.Second)
+			for processRunning(pid) && time.Now().Before(deadline) {
+				time.Sleep(10 * time.Millisecond)
+			}
+			if processRunning(pid) {
+				syscall.Kill(pid, syscall.SIGKILL)
+				t.Errorf("grandchild %d is still running after the timeout", pid)
+			}
+
+			gotSignals := make([]string, 0)
+			for _, e := range readLoggedSpans(t, logFile)[0].Events {
+				if e.Name == timeoutEventName {
+					for _, kv := range e.Attributes {
+						if kv.Key == "signal" {
+							gotSignals = append(gotSignals, kv.Value.GetStringValue())
+						}
+					}
+				}
+			}
+			if strings.Join(gotSignals, ",") != "SIGTERM,SIGKILL" {
+				t.Errorf("timeout events signalled %v, want [SIGTERM SIGKILL]", gotSignals)
+			}
+		})
+	}
+}
+
+func Test_startInProcessGroup(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "stdin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	c := exec.Command("true")
+	c.Stdin = f
+	startInProcessGroup(c)
+	if !inProcessGroup(c) {
+		t.Error("a command reading a file should get a process group of its own")
+	}
+
+	tty, err := os.OpenFile("/dev/ptmx", os.O_RDWR, 0)
+	if err != nil {
+		t.Skipf("no pseudo-terminal: %v", err)
+	}
+	defer tty.Close()
+	c = exec.Command("true")
+	c.Stdin = tty
+	startInProcessGroup(c)
+	if inProcessGroup(c) {
+		t.Error("a command reading a terminal should stay in the terminal's foreground group")
+	}
+}
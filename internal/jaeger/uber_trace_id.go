@@ -0,0 +1,175 @@
+package jaeger
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Header is Jaeger's trace context header: https://www.jaegertracing.io/docs/1.31/client-libraries/#propagation-format
+const Header = "uber-trace-id"
+
+// flags defined by the uber-trace-id format
+const (
+	FlagSampled = 0x01
+	FlagDebug   = 0x02
+)
+
+// errors returned when parsing an UberTraceID
+var (
+	ErrInvalidHeader       = errors.New("invalid uber-trace-id")
+	ErrInvalidTraceID      = errors.New("invalid uber-trace-id trace id")
+	ErrInvalidSpanID       = errors.New("invalid uber-trace-id span id")
+	ErrInvalidParentSpanID = errors.New("invalid uber-trace-id parent span id")
+	ErrInvalidFlags        = errors.New("invalid uber-trace-id flags")
+)
+
+// UberTraceID implements the uber-trace-id header: {trace-id}:{span-id}:{parent-span-id}:{flags}
+type UberTraceID struct {
+	TraceID      trace.TraceID
+	SpanID       trace.SpanID
+	ParentSpanID trace.SpanID
+	Flags        byte
+}
+
+// ParseUberTraceID parses an uber-trace-id header value, which may be URL-encoded; ids may
+// omit leading zeros and a parent span id of 0 means there is none
+func ParseUberTraceID(s string) (UberTraceID, error) {
+	unescaped, err := url.QueryUnescape(s)
+	if err != nil {
+		return UberTraceID{}, fmt.Errorf("%w: '%s'", ErrInvalidHeader, s)
+	}
+	parts := strings.Split(unescaped, ":")
+	if len(parts) != 4 {
+		return UberTraceID{}, fmt.Errorf("%w: '%s'", ErrInvalidHeader, s)
+	}
+
+	u := UberTraceID{}
+	if err := decodeHexID(u.TraceID[:], parts[0]); err != nil || !u.TraceID.IsValid() {
+		return UberTraceID{}, fmt.Errorf("%w: '%s'", ErrInvalidTraceID, parts[0])
+	}
+	if err := decodeHexID(u.SpanID[:], parts[1]); err != nil || !u.SpanID.IsValid() {
+		return UberTraceID{}, fmt.Errorf("%w: '%s'", ErrInvalidSpanID, parts[1])
+	}
+	if err := decodeHexID(u.ParentSpanID[:], parts[2]); err != nil {
+		return UberTraceID{}, fmt.Errorf("%w: '%s'", ErrInvalidParentSpanID, parts[2])
+	}
+	flags, err := strconv.ParseUint(parts[3], 16, 8)
+	if err != nil {
+		return UberTraceID{}, fmt.Errorf("%w: '%s'", ErrInvalidFlags, parts[3])
+	}
+	u.Flags = byte(flags)
+	return u, nil
+}
+
+// decodeHexID left-pads s with zeros and decodes it into dst
+func decodeHexID(dst []byte, s string) error {
+	if s == "" || len(s) > 2*len(dst) {
+		return fmt.Errorf("want 1 to %d hex digits", 2*len(dst))
+	}
+	_, err := hex.Decode(dst, []byte(strings.Repeat("0", 2*len(dst)-len(s))+s))
+	return err
+}
+
+// NewUberTraceIDFromContext creates an UberTraceID for the span in ctx, including its parent
+// span id when the span was started by the SDK and the debug flag when ctx carries it
+func NewUberTraceIDFromContext(ctx context.Context) UberTraceID {
+	span := trace.SpanFromContext(ctx)
+	sc := span.SpanContext()
+	u := UberTraceID{TraceID: sc.TraceID(), SpanID: sc.SpanID()}
+	if s, ok := span.(sdktrace.ReadOnlySpan); ok {
+		u.ParentSpanID = s.Parent().SpanID()
+	}
+	if sc.IsSampled() {
+		u.Flags |= FlagSampled
+	}
+	if IsDebug(ctx) {
+		u.Flags |= FlagSampled | FlagDebug
+	}
+	return u
+}
+
+// String implements the Stringer interface for UberTraceID; a 128-bit trace id whose high
+// half is zero is written with 16 digits as 64-bit Jaeger clients expect
+func (u UberTraceID) String() string {
+	traceID := u.TraceID.String()
+	if strings.HasPrefix(traceID, strings.Repeat("0", 16)) {
+		traceID = traceID[16:]
+	}
+	parentSpanID := "0"
+	if u.ParentSpanID.IsValid() {
+		parentSpanID = u.ParentSpanID.String()
+	}
+	return fmt.Sprintf("%s:%s:%s:%x", traceID, u.SpanID, parentSpanID, u.Flags)
+}
+
+// Sampled reports whether the sampled (or debug) flag is set
+func (u UberTraceID) Sampled() bool {
+	return u.Flags&(FlagSampled|FlagDebug) != 0
+}
+
+// Debug reports whether the debug flag is set
+func (u UberTraceID) Debug() bool {
+	return u.Flags&FlagDebug != 0
+}
+
+// SpanContext returns the remote trace.SpanContext u describes
+func (u UberTraceID) SpanContext() trace.SpanContext {
+	cfg := trace.SpanContextConfig{TraceID: u.TraceID, SpanID: u.SpanID, Remote: true}
+	if u.Sampled() {
+		cfg.TraceFlags = trace.FlagsSampled
+	}
+	return trace.NewSpanContext(cfg)
+}
+
+type debugKey struct{}
+
+// WithDebug returns a copy of ctx marking the trace as debug so that it is propagated with
+// the debug flag
+func WithDebug(ctx context.Context) context.Context {
+	return context.WithValue(ctx, debugKey{}, true)
+}
+
+// IsDebug reports whether ctx marks the trace as debug
+func IsDebug(ctx context.Context) bool {
+	debug, _ := ctx.Value(debugKey{}).(bool)
+	return debug
+}
+
+// Propagator propagates span context in the uber-trace-id header
+type Propagator struct{}
+
+var _ propagation.TextMapPropagator = Propagator{}
+
+// Inject implements propagation.TextMapPropagator
+func (Propagator) Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return
+	}
+	carrier.Set(Header, NewUberTraceIDFromContext(ctx).String())
+}
+
+// Extract implements propagation.TextMapPropagator; an invalid header is ignored
+func (Propagator) Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	u, err := ParseUberTraceID(carrier.Get(Header))
+	if err != nil {
+		return ctx
+	}
+	if u.Debug() {
+		ctx = WithDebug(ctx)
+	}
+	return trace.ContextWithRemoteSpanContext(ctx, u.SpanContext())
+}
+
+// Fields implements propagation.TextMapPropagator
+func (Propagator) Fields() []string {
+	return []string{Header}
+}
@@ -0,0 +1,87 @@
+package jaeger
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestParseUberTraceID(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		want        string
+		wantSampled bool
+		wantDebug   bool
+		wantErr     error
+	}{
+		{name: "128-bit", in: "4bf92f3577b34da6a3ce929d0e0e4736:00f067aa0ba902b7:0:1", want: "4bf92f3577b34da6a3ce929d0e0e4736:00f067aa0ba902b7:0:1", wantSampled: true},
+		{name: "64-bit", in: "a3ce929d0e0e4736:00f067aa0ba902b7:0:0", want: "a3ce929d0e0e4736:00f067aa0ba902b7:0:0"},
+		{name: "leading zeros omitted", in: "3ce929d0e0e4736:f067aa0ba902b7:0:1", want: "03ce929d0e0e4736:00f067aa0ba902b7:0:1", wantSampled: true},
+		{name: "with parent", in: "a3ce929d0e0e4736:00f067aa0ba902b7:5e3ac9a4f6e3b90:1", want: "a3ce929d0e0e4736:00f067aa0ba902b7:05e3ac9a4f6e3b90:1", wantSampled: true},
+		{name: "debug", in: "a3ce929d0e0e4736:00f067aa0ba902b7:0:3", want: "a3ce929d0e0e4736:00f067aa0ba902b7:0:3", wantSampled: true, wantDebug: true},
+		{name: "debug without sampled", in: "a3ce929d0e0e4736:00f067aa0ba902b7:0:2", want: "a3ce929d0e0e4736:00f067aa0ba902b7:0:2", wantSampled: true, wantDebug: true},
+		{name: "url-encoded", in: "a3ce929d0e0e4736%3A00f067aa0ba902b7%3A0%3A1", want: "a3ce929d0e0e4736:00f067aa0ba902b7:0:1", wantSampled: true},
+		{name: "empty", in: "", wantErr: ErrInvalidHeader},
+		{name: "too few fields", in: "a3ce929d0e0e4736:00f067aa0ba902b7:1", wantErr: ErrInvalidHeader},
+		{name: "all-zero trace id", in: "0:00f067aa0ba902b7:0:1", wantErr: ErrInvalidTraceID},
+		{name: "trace id too long", in: "04bf92f3577b34da6a3ce929d0e0e4736:00f067aa0ba902b7:0:1", wantErr: ErrInvalidTraceID},
+		{name: "all-zero span id", in: "a3ce929d0e0e4736:0:0:1", wantErr: ErrInvalidSpanID},
+		{name: "invalid parent", in: "a3ce929d0e0e4736:00f067aa0ba902b7:x:1", wantErr: ErrInvalidParentSpanID},
+		{name: "invalid flags", in: "a3ce929d0e0e4736:00f067aa0ba902b7:0:x", wantErr: ErrInvalidFlags},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := ParseUberTraceID(tt.in)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseUberTraceID() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := u.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+			if u.Sampled() != tt.wantSampled {
+				t.Errorf("Sampled() = %v, want %v", u.Sampled(), tt.wantSampled)
+			}
+			if u.Debug() != tt.wantDebug {
+				t.Errorf("Debug() = %v, want %v", u.Debug(), tt.wantDebug)
+			}
+		})
+	}
+}
+
+func TestPropagator(t *testing.T) {
+	tests := []struct {
+		name      string
+		parent    string
+		wantFlags string
+	}{
+		{name: "sampled", parent: "4bf92f3577b34da6a3ce929d0e0e4736:00f067aa0ba902b7:0:1", wantFlags: "1"},
+		{name: "debug", parent: "4bf92f3577b34da6a3ce929d0e0e4736:00f067aa0ba902b7:0:2", wantFlags: "3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := Propagator{}.Extract(context.Background(), propagation.MapCarrier{Header: tt.parent})
+			parent := trace.SpanContextFromContext(ctx)
+			if !parent.IsValid() || !parent.IsRemote() || !parent.IsSampled() {
+				t.Fatalf("Extract() = %v, want a sampled remote span context", parent)
+			}
+
+			ctx, span := sdktrace.NewTracerProvider().Tracer("test").Start(ctx, "child")
+			defer span.End()
+			carrier := propagation.MapCarrier{}
+			Propagator{}.Inject(ctx, carrier)
+			sc := span.SpanContext()
+			want := sc.TraceID().String() + ":" + sc.SpanID().String() + ":00f067aa0ba902b7:" + tt.wantFlags
+			if got := carrier.Get(Header); got != want {
+				t.Errorf("Inject() = %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package otlpjson
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// The OTLP/JSON encoding differs from the canonical protobuf JSON mapping: trace and span
+// IDs are hex strings rather than base64 and enums are integers rather than names.
+// - https://github.com/open-telemetry/opentelemetry-specification/blob/v1.13.0/specification/protocol/otlp.md#json-protobuf-encoding
+
+// idFields are the OTLP message fields which hold trace or span IDs
+var idFields = map[string]bool{
+	"traceId":      true,
+	"spanId":       true,
+	"parentSpanId": true,
+}
+
+// Marshal encodes m as a single line of OTLP/JSON (without a trailing newline)
+func Marshal(m proto.Message) ([]byte, error) {
+	b, err := protojson.MarshalOptions{UseEnumNumbers: true}.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return convertIDs(b, base64ToHex)
+}
+
+// Unmarshal decodes a single OTLP/JSON document into m
+func Unmarshal(b []byte, m proto.Message) error {
+	b, err := convertIDs(b, hexToBase64)
+	if err != nil {
+		return err
+	}
+	return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(b, m)
+}
+
+// convertIDs re-encodes every trace and span ID field in the JSON document b
+func convertIDs(b []byte, convert func(string) (string, error)) ([]byte, error) {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var doc interface{}
+	if err := d.Decode(&doc); err != nil {
+		return nil, err
+	}
+	if err := walkIDs(doc, convert); err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc)
+}
+
+func walkIDs(v interface{}, convert func(string) (string, error)) error {
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		for k, child := range vv {
+			if s, ok := child.(string); ok && idFields[k] {
+				converted, err := convert(s)
+				if err != nil {
+					return fmt.Errorf("invalid %s '%s': %w", k, s, err)
+				}
+				vv[k] = converted
+				continue
+			}
+			if err := walkIDs(child, convert); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, child := range vv {
+			if err := walkIDs(child, convert); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func base64ToHex(s string) (string, error) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hexToBase64(s string) (string, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,118 @@
+package otlpjson
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	collectortracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func testResourceSpans() []*tracepb.ResourceSpans {
+	return []*tracepb.ResourceSpans{
+		{
+			InstrumentationLibrarySpans: []*tracepb.InstrumentationLibrarySpans{
+				{
+					Spans: []*tracepb.Span{
+						{
+							TraceId:           []byte{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+							SpanId:            []byte{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+							ParentSpanId:      []byte{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb8},
+							Name:              "Run",
+							Kind:              tracepb.Span_SPAN_KIND_INTERNAL,
+							StartTimeUnixNano: 1645300000000000000,
+							EndTimeUnixNano:   1645300001000000000,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	b, err := Marshal(&collectortracepb.ExportTraceServiceRequest{ResourceSpans: testResourceSpans()})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	s := string(b)
+	for _, want := range []string{`"traceId":"4bf92f3577b34da6a3ce929d0e0e4736"`, `"spanId":"00f067aa0ba902b7"`, `"parentSpanId":"00f067aa0ba902b8"`, `"kind":1`} {
+		if !strings.Contains(s, want) {
+			t.Errorf("Marshal() = %s, want it to contain %s", s, want)
+		}
+	}
+	if strings.Contains(s, "\n") {
+		t.Errorf("Marshal() = %s, want a single line", s)
+	}
+}
+
+func TestUnmarshal_roundTrip(t *testing.T) {
+	want := &collectortracepb.ExportTraceServiceRequest{ResourceSpans: testResourceSpans()}
+	b, err := Marshal(want)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	got := &collectortracepb.ExportTraceServiceRequest{}
+	if err := Unmarshal(b, got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !proto.Equal(got, want) {
+		t.Errorf("Unmarshal() got = %v, want %v", got, want)
+	}
+}
+
+func TestUnmarshal_invalidID(t *testing.T) {
+	err := Unmarshal([]byte(`{"resourceSpans":[{"instrumentationLibrarySpans":[{"spans":[{"traceId":"not-hex"}]}]}]}`), &collectortracepb.ExportTraceServiceRequest{})
+	if err == nil {
+		t.Errorf("Unmarshal() expected an error for a non-hex traceId")
+	}
+}
+
+func TestTraceClient_UploadTraces(t *testing.T) {
+	var buf bytes.Buffer
+	c := NewTraceClient(&buf)
+	for i := 0; i < 2; i++ {
+		if err := c.UploadTraces(context.Background(), testResourceSpans()); err != nil {
+			t.Fatalf("UploadTraces() error = %v", err)
+		}
+	}
+	if lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n"); len(lines) != 2 {
+		t.Errorf("UploadTraces() wrote %d lines, want 2", len(lines))
+	}
+}
+
+func TestReadTraceRequests(t *testing.T) {
+	var buf bytes.Buffer
+	c := NewTraceClient(&buf)
+	for i := 0; i < 2; i++ {
+		if err := c.UploadTraces(context.Background(), testResourceSpans()); err != nil {
+			t.Fatalf("UploadTraces() error = %v", err)
+		}
+		buf.WriteString("\n")
+	}
+
+	got := 0
+	err := ReadTraceRequests(&buf, func(req *collectortracepb.ExportTraceServiceRequest) error {
+		got++
+		if !proto.Equal(req, &collectortracepb.ExportTraceServiceRequest{ResourceSpans: testResourceSpans()}) {
+			t.Errorf("ReadTraceRequests() got = %v", req)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ReadTraceRequests() error = %v", err)
+	}
+	if got != 2 {
+		t.Errorf("ReadTraceRequests() read %d requests, want 2", got)
+	}
+}
+
+func TestReadTraceRequests_notOTLP(t *testing.T) {
+	err := ReadTraceRequests(strings.NewReader("{\n\t\"Name\": \"Run\",\n"), func(*collectortracepb.ExportTraceServiceRequest) error { return nil })
+	if err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("ReadTraceRequests() error = %v, want an error for line 1", err)
+	}
+}
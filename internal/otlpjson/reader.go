@@ -0,0 +1,38 @@
+package otlpjson
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	collectortracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+)
+
+// maxLineSize bounds a single OTLP/JSON line; one line holds a whole export batch
+const maxLineSize = 64 * 1024 * 1024
+
+// ReadTraceRequests reads one ExportTraceServiceRequest per line from r, as written by
+// TraceClient, and calls fn with each; blank lines are skipped
+func ReadTraceRequests(r io.Reader, fn func(req *collectortracepb.ExportTraceServiceRequest) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		req := &collectortracepb.ExportTraceServiceRequest{}
+		if err := Unmarshal([]byte(line), req); err != nil {
+			return fmt.Errorf("line %d: not an OTLP/JSON trace request: %w", lineNumber, err)
+		}
+		if len(req.ResourceSpans) == 0 {
+			// other JSON documents (e.g. the pretty or compact formats) decode into an empty request
+			return fmt.Errorf("line %d: not an OTLP/JSON trace request: no resourceSpans", lineNumber)
+		}
+		if err := fn(req); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
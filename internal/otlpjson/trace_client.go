@@ -0,0 +1,51 @@
+package otlpjson
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	collectortracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// TraceClient is an otlptrace.Client which writes each batch of spans to an io.Writer as one
+// line of OTLP/JSON holding an ExportTraceServiceRequest, the same format written by the
+// OpenTelemetry Collector's file exporter
+type TraceClient struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// compile time assertion that TraceClient implements otlptrace.Client
+var _ otlptrace.Client = &TraceClient{}
+
+// NewTraceClient creates a TraceClient which writes to w
+func NewTraceClient(w io.Writer) *TraceClient {
+	return &TraceClient{w: w}
+}
+
+// Start implements otlptrace.Client
+func (c *TraceClient) Start(context.Context) error {
+	return nil
+}
+
+// Stop implements otlptrace.Client
+func (c *TraceClient) Stop(context.Context) error {
+	return nil
+}
+
+// UploadTraces implements otlptrace.Client
+func (c *TraceClient) UploadTraces(_ context.Context, protoSpans []*tracepb.ResourceSpans) error {
+	b, err := Marshal(&collectortracepb.ExportTraceServiceRequest{ResourceSpans: protoSpans})
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// write each line with a single call so that appends from concurrent processes do not interleave
+	_, err = c.w.Write(append(b, '\n'))
+	return err
+}
@@ -0,0 +1,154 @@
+// Package otlplogs exports log records over OTLP; the OpenTelemetry Go SDK this module
+// builds on has no logs signal so records are built from the OTLP protos directly
+package otlplogs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/davidalpert/opentracer/internal/otlpproto"
+	"go.opentelemetry.io/otel/sdk/resource"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+)
+
+// defaults for batching log records
+const (
+	DefaultMaxBatchSize  = 512
+	DefaultFlushInterval = time.Second
+)
+
+// Client uploads batches of log records
+type Client interface {
+	UploadLogs(ctx context.Context, resourceLogs []*logspb.ResourceLogs) error
+}
+
+// Exporter batches log records and uploads them with a Client in the background
+type Exporter struct {
+	client   Client
+	resource *resourcepb.Resource
+	library  *commonpb.InstrumentationLibrary
+
+	MaxBatchSize  int
+	FlushInterval time.Duration
+
+	mu      sync.Mutex
+	pending []*logspb.LogRecord
+	err     error
+
+	uploadMu sync.Mutex
+	full     chan struct{}
+	stop     chan struct{}
+	wg       sync.WaitGroup
+	started  sync.Once
+}
+
+// NewExporter creates an Exporter which describes its records with res and the named
+// instrumentation library
+func NewExporter(client Client, res *resource.Resource, library, version string) *Exporter {
+	return &Exporter{
+		client:        client,
+		resource:      otlpproto.ResourceToProto(res),
+		library:       &commonpb.InstrumentationLibrary{Name: library, Version: version},
+		MaxBatchSize:  DefaultMaxBatchSize,
+		FlushInterval: DefaultFlushInterval,
+		full:          make(chan struct{}, 1),
+		stop:          make(chan struct{}),
+	}
+}
+
+// Emit queues a record for export
+func (e *Exporter) Emit(record *logspb.LogRecord) {
+	e.started.Do(e.start)
+	e.mu.Lock()
+	e.pending = append(e.pending, record)
+	full := len(e.pending) >= e.MaxBatchSize
+	e.mu.Unlock()
+	if full {
+		select {
+		case e.full <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (e *Exporter) start() {
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+		ticker := time.NewTicker(e.FlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-e.full:
+			case <-e.stop:
+				return
+			}
+			e.flush(context.Background())
+		}
+	}()
+}
+
+// flush uploads every queued record, keeping the first error
+func (e *Exporter) flush(ctx context.Context) error {
+	e.uploadMu.Lock()
+	defer e.uploadMu.Unlock()
+	for {
+		e.mu.Lock()
+		batch := e.pending
+		if len(batch) > e.MaxBatchSize {
+			batch = batch[:e.MaxBatchSize]
+		}
+		e.pending = e.pending[len(batch):]
+		e.mu.Unlock()
+		if len(batch) == 0 {
+			return nil
+		}
+
+		err := e.client.UploadLogs(ctx, []*logspb.ResourceLogs{{
+			Resource: e.resource,
+			InstrumentationLibraryLogs: []*logspb.InstrumentationLibraryLogs{{
+				InstrumentationLibrary: e.library,
+				LogRecords:             batch,
+			}},
+		}})
+		if err != nil {
+			e.mu.Lock()
+			if e.err == nil {
+				e.err = err
+			}
+			e.mu.Unlock()
+			return err
+		}
+	}
+}
+
+// Shutdown stops the background exporter, uploads any queued records within ctx and returns
+// the first error seen since the Exporter was created
+func (e *Exporter) Shutdown(ctx context.Context) error {
+	e.started.Do(func() {})
+	close(e.stop)
+	e.wg.Wait()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		e.flush(ctx)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		e.mu.Lock()
+		if e.err == nil {
+			e.err = ctx.Err()
+		}
+		e.mu.Unlock()
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.err
+}
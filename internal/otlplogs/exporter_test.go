@@ -0,0 +1,137 @@
+package otlplogs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/davidalpert/opentracer/internal/otlpproto"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	collectorlogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+type fakeClient struct {
+	mu      sync.Mutex
+	batches [][]*logspb.ResourceLogs
+	err     error
+}
+
+func (c *fakeClient) UploadLogs(_ context.Context, resourceLogs []*logspb.ResourceLogs) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return c.err
+	}
+	c.batches = append(c.batches, resourceLogs)
+	return nil
+}
+
+func TestExporter(t *testing.T) {
+	tests := []struct {
+		name        string
+		records     int
+		clientErr   error
+		wantBatches int
+	}{
+		{name: "one batch", records: 2, wantBatches: 1},
+		{name: "split into batches", records: 5, wantBatches: 3},
+		{name: "upload fails", records: 1, clientErr: errors.New("unavailable")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeClient{err: tt.clientErr}
+			res := resource.NewSchemaless(attribute.String("service.name", "test"))
+			e := NewExporter(client, res, "opentracer", "v1")
+			e.MaxBatchSize = 2
+			e.FlushInterval = time.Hour
+			for i := 0; i < tt.records; i++ {
+				e.Emit(&logspb.LogRecord{Body: otlpproto.StringValue("line")})
+			}
+
+			err := e.Shutdown(context.Background())
+			if !errors.Is(err, tt.clientErr) {
+				t.Fatalf("Shutdown() error = %v, want %v", err, tt.clientErr)
+			}
+			if len(client.batches) != tt.wantBatches {
+				t.Fatalf("uploaded %d batches, want %d", len(client.batches), tt.wantBatches)
+			}
+			records := 0
+			for _, batch := range client.batches {
+				rl := batch[0]
+				if got := rl.Resource.Attributes[0].Value.GetStringValue(); got != "test" {
+					t.Errorf("resource service.name = %q, want test", got)
+				}
+				if got := rl.InstrumentationLibraryLogs[0].InstrumentationLibrary.Name; got != "opentracer" {
+					t.Errorf("instrumentation library = %q, want opentracer", got)
+				}
+				records += len(rl.InstrumentationLibraryLogs[0].LogRecords)
+			}
+			if tt.clientErr == nil && records != tt.records {
+				t.Errorf("uploaded %d records, want %d", records, tt.records)
+			}
+		})
+	}
+}
+
+func TestHTTPClient_UploadLogs(t *testing.T) {
+	var gotPath, gotHeader string
+	var got collectorlogspb.ExportLogsServiceRequest
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotHeader = r.Header.Get("api-key")
+		body, _ := io.ReadAll(r.Body)
+		if err := proto.Unmarshal(body, &got); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer ts.Close()
+
+	c, err := NewHTTPClient(ts.Listener.Addr().String(), map[string]string{"api-key": "secret"}, nil, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.UploadLogs(context.Background(), []*logspb.ResourceLogs{{}}); err != nil {
+		t.Fatalf("UploadLogs() error = %v", err)
+	}
+	if gotPath != DefaultLogsPath {
+		t.Errorf("path = %q, want %q", gotPath, DefaultLogsPath)
+	}
+	if gotHeader != "secret" {
+		t.Errorf("api-key header = %q, want secret", gotHeader)
+	}
+	if len(got.ResourceLogs) != 1 {
+		t.Errorf("received %d resource logs, want 1", len(got.ResourceLogs))
+	}
+}
+
+func TestNewHTTPClient(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		wantURL  string
+		wantErr  bool
+	}{
+		{endpoint: "localhost:4318", wantURL: "http://localhost:4318/v1/logs"},
+		{endpoint: "https://collector:4318", wantURL: "https://collector:4318/v1/logs"},
+		{endpoint: "http://collector:4318/custom/logs", wantURL: "http://collector:4318/custom/logs"},
+		{endpoint: "grpc://collector:4317", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.endpoint, func(t *testing.T) {
+			c, err := NewHTTPClient(tt.endpoint, nil, nil, 0)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewHTTPClient() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && c.URL() != tt.wantURL {
+				t.Errorf("URL() = %q, want %q", c.URL(), tt.wantURL)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package otlplogs
+
+import (
+	"context"
+	"crypto/tls"
+	"time"
+
+	"github.com/davidalpert/opentracer/internal/otlpproto"
+	collectorlogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+// DefaultLogsPath is the OTLP/HTTP path for logs used when an endpoint does not include one
+const DefaultLogsPath = "/v1/logs"
+
+// HTTPClient is a Client which sends batches of log records to an OTLP/HTTP endpoint as
+// binary protobuf
+type HTTPClient struct {
+	*otlpproto.HTTPClient
+}
+
+// compile time assertion that HTTPClient implements Client
+var _ Client = &HTTPClient{}
+
+// NewHTTPClient creates an HTTPClient for endpoint, either a host:port or a full URL; the
+// scheme defaults to https when tlsCfg is set and the path to DefaultLogsPath
+func NewHTTPClient(endpoint string, headers map[string]string, tlsCfg *tls.Config, timeout time.Duration) (*HTTPClient, error) {
+	c, err := otlpproto.NewHTTPClient(endpoint, DefaultLogsPath, headers, tlsCfg, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return &HTTPClient{HTTPClient: c}, nil
+}
+
+// UploadLogs implements Client
+func (c *HTTPClient) UploadLogs(ctx context.Context, resourceLogs []*logspb.ResourceLogs) error {
+	return c.Post(ctx, &collectorlogspb.ExportLogsServiceRequest{ResourceLogs: resourceLogs})
+}
@@ -0,0 +1,124 @@
+package otlpmetrics
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/davidalpert/opentracer/internal/otlpjson"
+	"github.com/davidalpert/opentracer/internal/otlpproto"
+	collectormetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+// DefaultMetricsPath is the OTLP/HTTP path for metrics used when an endpoint does not include one
+const DefaultMetricsPath = "/v1/metrics"
+
+// Client uploads batches of metrics
+type Client interface {
+	UploadMetrics(ctx context.Context, resourceMetrics []*metricspb.ResourceMetrics) error
+}
+
+// HTTPClient is a Client which sends metrics to an OTLP/HTTP endpoint as binary protobuf
+type HTTPClient struct {
+	*otlpproto.HTTPClient
+}
+
+// compile time assertion that HTTPClient implements Client
+var _ Client = &HTTPClient{}
+
+// NewHTTPClient creates an HTTPClient for endpoint, either a host:port or a full URL; the
+// scheme defaults to https when tlsCfg is set and the path to DefaultMetricsPath
+func NewHTTPClient(endpoint string, headers map[string]string, tlsCfg *tls.Config, timeout time.Duration) (*HTTPClient, error) {
+	c, err := otlpproto.NewHTTPClient(endpoint, DefaultMetricsPath, headers, tlsCfg, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return &HTTPClient{HTTPClient: c}, nil
+}
+
+// UploadMetrics implements Client
+func (c *HTTPClient) UploadMetrics(ctx context.Context, resourceMetrics []*metricspb.ResourceMetrics) error {
+	return c.Post(ctx, &collectormetricspb.ExportMetricsServiceRequest{ResourceMetrics: resourceMetrics})
+}
+
+// GRPCClient is a Client which sends metrics to an OTLP/gRPC endpoint
+type GRPCClient struct {
+	endpoint string
+	headers  map[string]string
+	tlsCfg   *tls.Config
+	timeout  time.Duration
+}
+
+// compile time assertion that GRPCClient implements Client
+var _ Client = &GRPCClient{}
+
+// NewGRPCClient creates a GRPCClient for endpoint; TLS is used when tlsCfg is set or the
+// endpoint starts with https://
+func NewGRPCClient(endpoint string, headers map[string]string, tlsCfg *tls.Config, timeout time.Duration) *GRPCClient {
+	if tlsCfg == nil && strings.HasPrefix(endpoint, "https://") {
+		tlsCfg = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	// the grpc client expects a bare host:port
+	endpoint = strings.TrimPrefix(strings.TrimPrefix(endpoint, "https://"), "http://")
+	return &GRPCClient{endpoint: endpoint, headers: headers, tlsCfg: tlsCfg, timeout: timeout}
+}
+
+// UploadMetrics implements Client; each upload uses a connection of its own as a run only
+// exports metrics once
+func (c *GRPCClient) UploadMetrics(ctx context.Context, resourceMetrics []*metricspb.ResourceMetrics) error {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	creds := insecure.NewCredentials()
+	if c.tlsCfg != nil {
+		creds = credentials.NewTLS(c.tlsCfg)
+	}
+	conn, err := grpc.DialContext(ctx, c.endpoint, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if len(c.headers) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, metadata.New(c.headers))
+	}
+	_, err = collectormetricspb.NewMetricsServiceClient(conn).Export(ctx, &collectormetricspb.ExportMetricsServiceRequest{ResourceMetrics: resourceMetrics})
+	return err
+}
+
+// JSONClient is a Client which writes each batch of metrics to an io.Writer as one line of
+// OTLP/JSON holding an ExportMetricsServiceRequest
+type JSONClient struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// compile time assertion that JSONClient implements Client
+var _ Client = &JSONClient{}
+
+// NewJSONClient creates a JSONClient which writes to w
+func NewJSONClient(w io.Writer) *JSONClient {
+	return &JSONClient{w: w}
+}
+
+// UploadMetrics implements Client
+func (c *JSONClient) UploadMetrics(_ context.Context, resourceMetrics []*metricspb.ResourceMetrics) error {
+	b, err := otlpjson.Marshal(&collectormetricspb.ExportMetricsServiceRequest{ResourceMetrics: resourceMetrics})
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err = c.w.Write(append(b, '\n'))
+	return err
+}
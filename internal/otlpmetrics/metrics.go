@@ -0,0 +1,74 @@
+// Package otlpmetrics builds and exports OTLP metrics for a single run; the OpenTelemetry Go
+// SDK this module builds on has no stable metrics signal so the protos are built directly
+package otlpmetrics
+
+import (
+	"time"
+
+	"github.com/davidalpert/opentracer/internal/otlpproto"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+)
+
+// DeltaHistogram describes a single observation of value made between start and end
+func DeltaHistogram(name, description, unit string, bounds []float64, value float64, start, end time.Time, attrs []attribute.KeyValue) *metricspb.Metric {
+	counts := make([]uint64, len(bounds)+1)
+	bucket := len(bounds)
+	for i, b := range bounds {
+		if value <= b {
+			bucket = i
+			break
+		}
+	}
+	counts[bucket] = 1
+
+	return &metricspb.Metric{
+		Name:        name,
+		Description: description,
+		Unit:        unit,
+		Data: &metricspb.Metric_Histogram{Histogram: &metricspb.Histogram{
+			AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA,
+			DataPoints: []*metricspb.HistogramDataPoint{{
+				Attributes:        otlpproto.KeyValuesToProto(attrs),
+				StartTimeUnixNano: uint64(start.UnixNano()),
+				TimeUnixNano:      uint64(end.UnixNano()),
+				Count:             1,
+				Sum:               value,
+				BucketCounts:      counts,
+				ExplicitBounds:    bounds,
+			}},
+		}},
+	}
+}
+
+// DeltaCounter describes a monotonic sum which grew by value between start and end
+func DeltaCounter(name, description, unit string, value int64, start, end time.Time, attrs []attribute.KeyValue) *metricspb.Metric {
+	return &metricspb.Metric{
+		Name:        name,
+		Description: description,
+		Unit:        unit,
+		Data: &metricspb.Metric_Sum{Sum: &metricspb.Sum{
+			AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA,
+			IsMonotonic:            true,
+			DataPoints: []*metricspb.NumberDataPoint{{
+				Attributes:        otlpproto.KeyValuesToProto(attrs),
+				StartTimeUnixNano: uint64(start.UnixNano()),
+				TimeUnixNano:      uint64(end.UnixNano()),
+				Value:             &metricspb.NumberDataPoint_AsInt{AsInt: value},
+			}},
+		}},
+	}
+}
+
+// NewResourceMetrics groups metrics under res and the named instrumentation library
+func NewResourceMetrics(res *resource.Resource, library, version string, metrics ...*metricspb.Metric) []*metricspb.ResourceMetrics {
+	return []*metricspb.ResourceMetrics{{
+		Resource: otlpproto.ResourceToProto(res),
+		InstrumentationLibraryMetrics: []*metricspb.InstrumentationLibraryMetrics{{
+			InstrumentationLibrary: &commonpb.InstrumentationLibrary{Name: library, Version: version},
+			Metrics:                metrics,
+		}},
+	}}
+}
@@ -0,0 +1,99 @@
+package otlpmetrics
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/davidalpert/opentracer/internal/otlpjson"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	collectormetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestDeltaHistogram(t *testing.T) {
+	bounds := []float64{1, 10}
+	tests := []struct {
+		value      float64
+		wantBucket int
+	}{
+		{value: 0.5, wantBucket: 0},
+		{value: 1, wantBucket: 0},
+		{value: 5, wantBucket: 1},
+		{value: 60, wantBucket: 2},
+	}
+	for _, tt := range tests {
+		m := DeltaHistogram("d", "", "s", bounds, tt.value, time.Now(), time.Now(), nil)
+		dp := m.GetHistogram().DataPoints[0]
+		if len(dp.BucketCounts) != len(bounds)+1 || dp.BucketCounts[tt.wantBucket] != 1 {
+			t.Errorf("DeltaHistogram(%v) bucket counts = %v, want a count in bucket %d", tt.value, dp.BucketCounts, tt.wantBucket)
+		}
+		if dp.Count != 1 || dp.Sum != tt.value {
+			t.Errorf("DeltaHistogram(%v) count = %d sum = %v, want 1 and %v", tt.value, dp.Count, dp.Sum, tt.value)
+		}
+	}
+}
+
+func testResourceMetrics() []*metricspb.ResourceMetrics {
+	now := time.Now()
+	attrs := []attribute.KeyValue{attribute.Int("process.exit_code", 0)}
+	return NewResourceMetrics(resource.NewSchemaless(attribute.String("service.name", "test")), "opentracer", "v1",
+		DeltaCounter("runs", "", "{run}", 1, now, now, attrs),
+	)
+}
+
+func TestJSONClient_UploadMetrics(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewJSONClient(&buf).UploadMetrics(context.Background(), testResourceMetrics()); err != nil {
+		t.Fatalf("UploadMetrics() error = %v", err)
+	}
+	var got collectormetricspb.ExportMetricsServiceRequest
+	if err := otlpjson.Unmarshal(bytes.TrimSuffix(buf.Bytes(), []byte("\n")), &got); err != nil {
+		t.Fatalf("wrote invalid OTLP/JSON: %v", err)
+	}
+	if name := got.ResourceMetrics[0].InstrumentationLibraryMetrics[0].Metrics[0].Name; name != "runs" {
+		t.Errorf("metric name = %q, want runs", name)
+	}
+}
+
+type fakeMetricsService struct {
+	collectormetricspb.UnimplementedMetricsServiceServer
+	received []*collectormetricspb.ExportMetricsServiceRequest
+	apiKey   string
+}
+
+func (s *fakeMetricsService) Export(ctx context.Context, req *collectormetricspb.ExportMetricsServiceRequest) (*collectormetricspb.ExportMetricsServiceResponse, error) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get("api-key")) > 0 {
+		s.apiKey = md.Get("api-key")[0]
+	}
+	s.received = append(s.received, req)
+	return &collectormetricspb.ExportMetricsServiceResponse{}, nil
+}
+
+func TestGRPCClient_UploadMetrics(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := &fakeMetricsService{}
+	srv := grpc.NewServer()
+	collectormetricspb.RegisterMetricsServiceServer(srv, service)
+	go srv.Serve(l)
+	defer srv.Stop()
+
+	c := NewGRPCClient("http://"+l.Addr().String(), map[string]string{"api-key": "secret"}, nil, 5*time.Second)
+	if err := c.UploadMetrics(context.Background(), testResourceMetrics()); err != nil {
+		t.Fatalf("UploadMetrics() error = %v", err)
+	}
+	if len(service.received) != 1 {
+		t.Fatalf("received %d requests, want 1", len(service.received))
+	}
+	if service.apiKey != "secret" {
+		t.Errorf("api-key metadata = %q, want secret", service.apiKey)
+	}
+}
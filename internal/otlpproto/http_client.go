@@ -0,0 +1,86 @@
+package otlpproto
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// HTTPClient posts OTLP requests to an OTLP/HTTP endpoint as binary protobuf
+type HTTPClient struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+// NewHTTPClient creates an HTTPClient for endpoint, either a host:port or a full URL; the
+// scheme defaults to https when tlsCfg is set and the path to defaultPath
+func NewHTTPClient(endpoint, defaultPath string, headers map[string]string, tlsCfg *tls.Config, timeout time.Duration) (*HTTPClient, error) {
+	if !strings.Contains(endpoint, "://") {
+		scheme := "http"
+		if tlsCfg != nil {
+			scheme = "https"
+		}
+		endpoint = scheme + "://" + endpoint
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint '%s': %w", endpoint, err)
+	}
+	if u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("invalid endpoint '%s': expected host:port or an http(s) URL", endpoint)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = defaultPath
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if tlsCfg != nil {
+		transport.TLSClientConfig = tlsCfg
+	}
+	return &HTTPClient{
+		url:     u.String(),
+		headers: headers,
+		client:  &http.Client{Transport: transport, Timeout: timeout},
+	}, nil
+}
+
+// URL returns the URL requests are posted to
+func (c *HTTPClient) URL() string {
+	return c.url
+}
+
+// Post sends req and fails unless the endpoint responds with a 2xx status
+func (c *HTTPClient) Post(ctx context.Context, req proto.Message) error {
+	body, err := proto.Marshal(req)
+	if err != nil {
+		return err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/x-protobuf")
+	for k, v := range c.headers {
+		httpReq.Header.Set(k, v)
+	}
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s responded %s", c.url, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+// Package otlpproto converts SDK types to OTLP protos and posts OTLP/HTTP requests for the
+// signals the OpenTelemetry Go SDK this module builds on does not export itself
+package otlpproto
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+)
+
+// ResourceToProto converts an SDK resource into its OTLP form
+func ResourceToProto(res *resource.Resource) *resourcepb.Resource {
+	if res == nil {
+		return nil
+	}
+	return &resourcepb.Resource{Attributes: KeyValuesToProto(res.Attributes())}
+}
+
+// KeyValuesToProto converts attributes into their OTLP form
+func KeyValuesToProto(attrs []attribute.KeyValue) []*commonpb.KeyValue {
+	out := make([]*commonpb.KeyValue, 0, len(attrs))
+	for _, kv := range attrs {
+		out = append(out, &commonpb.KeyValue{Key: string(kv.Key), Value: valueToProto(kv.Value)})
+	}
+	return out
+}
+
+func valueToProto(v attribute.Value) *commonpb.AnyValue {
+	switch v.Type() {
+	case attribute.BOOL:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: v.AsBool()}}
+	case attribute.INT64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: v.AsInt64()}}
+	case attribute.FLOAT64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: v.AsFloat64()}}
+	case attribute.STRING:
+		return StringValue(v.AsString())
+	case attribute.BOOLSLICE, attribute.INT64SLICE, attribute.FLOAT64SLICE, attribute.STRINGSLICE:
+		values := make([]*commonpb.AnyValue, 0)
+		switch v.Type() {
+		case attribute.BOOLSLICE:
+			for _, b := range v.AsBoolSlice() {
+				values = append(values, valueToProto(attribute.BoolValue(b)))
+			}
+		case attribute.INT64SLICE:
+			for _, i := range v.AsInt64Slice() {
+				values = append(values, valueToProto(attribute.Int64Value(i)))
+			}
+		case attribute.FLOAT64SLICE:
+			for _, f := range v.AsFloat64Slice() {
+				values = append(values, valueToProto(attribute.Float64Value(f)))
+			}
+		case attribute.STRINGSLICE:
+			for _, s := range v.AsStringSlice() {
+				values = append(values, StringValue(s))
+			}
+		}
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_ArrayValue{ArrayValue: &commonpb.ArrayValue{Values: values}}}
+	default:
+		return StringValue(v.Emit())
+	}
+}
+
+// StringValue wraps s as an OTLP AnyValue
+func StringValue(s string) *commonpb.AnyValue {
+	return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: s}}
+}
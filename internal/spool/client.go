@@ -0,0 +1,44 @@
+package spool
+
+import (
+	"context"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// Client is an otlptrace.Client which writes any batch its wrapped client fails to upload
+// to a Spool instead of losing it
+type Client struct {
+	otlptrace.Client
+	spool   *Spool
+	spooled int32
+}
+
+// compile time assertion that Client implements otlptrace.Client
+var _ otlptrace.Client = &Client{}
+
+// NewClient wraps client so that failed uploads are written to s
+func NewClient(client otlptrace.Client, s *Spool) *Client {
+	return &Client{Client: client, spool: s}
+}
+
+// UploadTraces implements otlptrace.Client; it only returns an error if the batch could
+// neither be uploaded nor spooled
+func (c *Client) UploadTraces(ctx context.Context, protoSpans []*tracepb.ResourceSpans) error {
+	err := c.Client.UploadTraces(ctx, protoSpans)
+	if err == nil {
+		return nil
+	}
+	if spoolErr := c.spool.Write(protoSpans); spoolErr != nil {
+		return err
+	}
+	atomic.AddInt32(&c.spooled, 1)
+	return nil
+}
+
+// Spooled returns the number of batches written to the spool
+func (c *Client) Spooled() int {
+	return int(atomic.LoadInt32(&c.spooled))
+}
@@ -0,0 +1,242 @@
+package spool
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/davidalpert/opentracer/internal/otlpjson"
+	collectortracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// Spooled batches are stored one per file and named <created-unix-nanos>-<random>.<attempts>.json
+// so that the creation time and number of failed attempts survive without any extra metadata.
+// A batch is claimed for sending by renaming it with an .inflight suffix so that concurrent
+// (e.g. nested) opentracer processes never send the same batch twice.
+const (
+	batchSuffix    = ".json"
+	inflightSuffix = ".inflight"
+	tempPrefix     = ".tmp-"
+)
+
+// staleClaimAge is how long a claimed batch may stay in flight before another process reclaims it
+const staleClaimAge = 5 * time.Minute
+
+// Spool persists trace batches which could not be exported so that they can be sent later
+type Spool struct {
+	Dir string
+	// MaxAttempts is the number of failed sends after which a batch is dropped; 0 means no limit
+	MaxAttempts int
+	// MaxAge is the age after which a batch is dropped without being sent; 0 means no limit
+	MaxAge time.Duration
+	now    func() time.Time
+}
+
+// FlushResult counts what happened to the spooled batches during a Flush
+type FlushResult struct {
+	Sent     int `json:"sent"`
+	Retained int `json:"retained"`
+	Expired  int `json:"expired"`
+	Dropped  int `json:"dropped"`
+}
+
+// String implements Stringer
+func (r FlushResult) String() string {
+	return fmt.Sprintf("sent %d, retained %d, expired %d, and dropped %d spooled batches\n", r.Sent, r.Retained, r.Expired, r.Dropped)
+}
+
+// Add returns the sum of both results
+func (r FlushResult) Add(other FlushResult) FlushResult {
+	return FlushResult{
+		Sent:     r.Sent + other.Sent,
+		Retained: r.Retained + other.Retained,
+		Expired:  r.Expired + other.Expired,
+		Dropped:  r.Dropped + other.Dropped,
+	}
+}
+
+// New creates a Spool in dir, creating the directory if needed
+func New(dir string, maxAttempts int, maxAge time.Duration) (*Spool, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &Spool{Dir: dir, MaxAttempts: maxAttempts, MaxAge: maxAge, now: time.Now}, nil
+}
+
+// Write persists a batch of spans
+func (s *Spool) Write(protoSpans []*tracepb.ResourceSpans) error {
+	b, err := otlpjson.Marshal(&collectortracepb.ExportTraceServiceRequest{ResourceSpans: protoSpans})
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%d-%08x.0%s", s.now().UnixNano(), rand.Uint32(), batchSuffix)
+	// write to a temp file and rename so that a concurrent Flush never reads a partial batch
+	tmp, err := os.CreateTemp(s.Dir, tempPrefix)
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(append(b, '\n')); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(s.Dir, name))
+}
+
+// Flush sends spooled batches, oldest first, with upload; it stops at the first failed
+// send on the assumption that the endpoint is still unreachable
+func (s *Spool) Flush(ctx context.Context, upload func(context.Context, []*tracepb.ResourceSpans) error) (FlushResult, error) {
+	result := FlushResult{}
+
+	batches, err := s.list()
+	if err != nil {
+		return result, err
+	}
+
+	for i, b := range batches {
+		if s.MaxAge > 0 && s.now().Sub(b.created) > s.MaxAge {
+			if err := os.Remove(b.path); err == nil {
+				result.Expired++
+			}
+			continue
+		}
+
+		claimed, ok := s.claim(b)
+		if !ok {
+			// another process got there first
+			continue
+		}
+
+		req, err := readBatch(claimed)
+		if err != nil {
+			// a batch we cannot read will never send
+			os.Remove(claimed)
+			result.Dropped++
+			continue
+		}
+
+		if err := upload(ctx, req.ResourceSpans); err != nil {
+			if s.MaxAttempts > 0 && b.attempts+1 >= s.MaxAttempts {
+				os.Remove(claimed)
+				result.Dropped++
+			} else {
+				os.Rename(claimed, filepath.Join(s.Dir, b.name(b.attempts+1)))
+				result.Retained++
+			}
+			result.Retained += len(batches) - i - 1
+			return result, err
+		}
+
+		os.Remove(claimed)
+		result.Sent++
+	}
+
+	return result, nil
+}
+
+// batch describes a spooled batch file
+type batch struct {
+	path     string
+	prefix   string
+	created  time.Time
+	attempts int
+}
+
+func (b batch) name(attempts int) string {
+	return fmt.Sprintf("%s.%d%s", b.prefix, attempts, batchSuffix)
+}
+
+// list returns the spooled batches oldest first, reclaiming any stale in-flight batches
+func (s *Spool) list() ([]batch, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	batches := make([]batch, 0, len(entries))
+	for _, e := range entries {
+		name := e.Name()
+		if strings.HasSuffix(name, inflightSuffix) {
+			s.reclaimIfStale(filepath.Join(s.Dir, name))
+			continue
+		}
+		b, ok := parseBatchName(name)
+		if !ok {
+			continue
+		}
+		b.path = filepath.Join(s.Dir, name)
+		batches = append(batches, b)
+	}
+
+	sort.Slice(batches, func(i, j int) bool {
+		return batches[i].created.Before(batches[j].created)
+	})
+	return batches, nil
+}
+
+// claim renames a batch so that no other process sends it
+func (s *Spool) claim(b batch) (string, bool) {
+	claimed := fmt.Sprintf("%s.%d%s", b.path, os.Getpid(), inflightSuffix)
+	if err := os.Rename(b.path, claimed); err != nil {
+		return "", false
+	}
+	now := s.now()
+	os.Chtimes(claimed, now, now)
+	return claimed, true
+}
+
+// reclaimIfStale returns an in-flight batch left behind by a process which died to the spool
+func (s *Spool) reclaimIfStale(path string) {
+	info, err := os.Stat(path)
+	if err != nil || s.now().Sub(info.ModTime()) < staleClaimAge {
+		return
+	}
+	// strip the .<pid>.inflight suffix
+	original := strings.TrimSuffix(path, inflightSuffix)
+	original = original[:strings.LastIndex(original, ".")]
+	os.Rename(path, original)
+}
+
+// parseBatchName parses <created-unix-nanos>-<random>.<attempts>.json
+func parseBatchName(name string) (batch, bool) {
+	if !strings.HasSuffix(name, batchSuffix) || strings.HasPrefix(name, tempPrefix) {
+		return batch{}, false
+	}
+	parts := strings.Split(strings.TrimSuffix(name, batchSuffix), ".")
+	if len(parts) != 2 {
+		return batch{}, false
+	}
+	attempts, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return batch{}, false
+	}
+	created, err := strconv.ParseInt(strings.SplitN(parts[0], "-", 2)[0], 10, 64)
+	if err != nil {
+		return batch{}, false
+	}
+	return batch{prefix: parts[0], created: time.Unix(0, created), attempts: attempts}, true
+}
+
+func readBatch(path string) (*collectortracepb.ExportTraceServiceRequest, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	req := &collectortracepb.ExportTraceServiceRequest{}
+	if err := otlpjson.Unmarshal(b, req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
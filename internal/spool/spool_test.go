@@ -0,0 +1,154 @@
+package spool
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+func testBatch(name string) []*tracepb.ResourceSpans {
+	return []*tracepb.ResourceSpans{
+		{
+			InstrumentationLibrarySpans: []*tracepb.InstrumentationLibrarySpans{
+				{Spans: []*tracepb.Span{{Name: name, TraceId: make([]byte, 16), SpanId: make([]byte, 8)}}},
+			},
+		},
+	}
+}
+
+// newTestSpool creates a Spool with a controllable clock
+func newTestSpool(t *testing.T, maxAttempts int, maxAge time.Duration) (*Spool, *time.Time) {
+	t.Helper()
+	s, err := New(t.TempDir(), maxAttempts, maxAge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1645300000, 0)
+	s.now = func() time.Time { return now }
+	return s, &now
+}
+
+// recordingUpload records the names of the spans it uploads and fails when err is set
+type recordingUpload struct {
+	names []string
+	err   error
+}
+
+func (u *recordingUpload) upload(_ context.Context, protoSpans []*tracepb.ResourceSpans) error {
+	if u.err != nil {
+		return u.err
+	}
+	u.names = append(u.names, protoSpans[0].InstrumentationLibrarySpans[0].Spans[0].Name)
+	return nil
+}
+
+func spooledFiles(t *testing.T, s *Spool) int {
+	t.Helper()
+	entries, err := os.ReadDir(s.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(entries)
+}
+
+func TestSpool_Flush_sendsOldestFirst(t *testing.T) {
+	s, now := newTestSpool(t, 0, 0)
+	for _, name := range []string{"first", "second"} {
+		if err := s.Write(testBatch(name)); err != nil {
+			t.Fatal(err)
+		}
+		*now = now.Add(time.Second)
+	}
+
+	u := &recordingUpload{}
+	result, err := s.Flush(context.Background(), u.upload)
+	if err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if result != (FlushResult{Sent: 2}) {
+		t.Errorf("Flush() result = %+v", result)
+	}
+	if len(u.names) != 2 || u.names[0] != "first" || u.names[1] != "second" {
+		t.Errorf("Flush() sent %v, want [first second]", u.names)
+	}
+	if n := spooledFiles(t, s); n != 0 {
+		t.Errorf("spool has %d files after a successful flush, want 0", n)
+	}
+}
+
+func TestSpool_Flush_stopsAtFirstFailure(t *testing.T) {
+	s, now := newTestSpool(t, 3, 0)
+	for _, name := range []string{"first", "second"} {
+		if err := s.Write(testBatch(name)); err != nil {
+			t.Fatal(err)
+		}
+		*now = now.Add(time.Second)
+	}
+
+	u := &recordingUpload{err: errors.New("collector unavailable")}
+	for attempt := 1; attempt <= 2; attempt++ {
+		result, err := s.Flush(context.Background(), u.upload)
+		if err == nil {
+			t.Fatalf("Flush() expected an error")
+		}
+		if result != (FlushResult{Retained: 2}) {
+			t.Errorf("attempt %d: Flush() result = %+v", attempt, result)
+		}
+	}
+
+	// the third failure reaches --spool-max-attempts for the oldest batch only
+	result, _ := s.Flush(context.Background(), u.upload)
+	if result != (FlushResult{Retained: 1, Dropped: 1}) {
+		t.Errorf("Flush() result = %+v", result)
+	}
+
+	u.err = nil
+	result, err := s.Flush(context.Background(), u.upload)
+	if err != nil || result != (FlushResult{Sent: 1}) || u.names[0] != "second" {
+		t.Errorf("Flush() result = %+v, err = %v, sent = %v", result, err, u.names)
+	}
+}
+
+func TestSpool_Flush_expiresOldBatches(t *testing.T) {
+	s, now := newTestSpool(t, 0, time.Hour)
+	if err := s.Write(testBatch("old")); err != nil {
+		t.Fatal(err)
+	}
+	*now = now.Add(2 * time.Hour)
+	if err := s.Write(testBatch("new")); err != nil {
+		t.Fatal(err)
+	}
+
+	u := &recordingUpload{}
+	result, err := s.Flush(context.Background(), u.upload)
+	if err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if result != (FlushResult{Sent: 1, Expired: 1}) || len(u.names) != 1 || u.names[0] != "new" {
+		t.Errorf("Flush() result = %+v, sent = %v", result, u.names)
+	}
+}
+
+func TestClient_UploadTraces_spoolsFailures(t *testing.T) {
+	s, _ := newTestSpool(t, 0, 0)
+	c := NewClient(failingClient{}, s)
+	if err := c.UploadTraces(context.Background(), testBatch("failed")); err != nil {
+		t.Fatalf("UploadTraces() error = %v", err)
+	}
+	if c.Spooled() != 1 || spooledFiles(t, s) != 1 {
+		t.Errorf("UploadTraces() spooled %d batches into %d files, want 1", c.Spooled(), spooledFiles(t, s))
+	}
+}
+
+// failingClient is an otlptrace.Client whose uploads always fail
+type failingClient struct{}
+
+func (failingClient) Start(context.Context) error { return nil }
+func (failingClient) Stop(context.Context) error  { return nil }
+func (failingClient) UploadTraces(context.Context, []*tracepb.ResourceSpans) error {
+	return errors.New("collector unavailable")
+}
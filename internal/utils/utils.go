@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
 	"os"
 )
@@ -14,9 +15,21 @@ func StringInSlice(list []string, q string) bool {
 	return false
 }
 
+// exitCoder is implemented by errors which carry the exit code the process should exit with
+type exitCoder interface {
+	ExitCode() int
+}
+
+// ExitIfErr exits when err is set; errors carrying an exit code exit with that code
+// silently (e.g. to pass through a wrapped command's exit code), all others print and exit 1
 func ExitIfErr(err error) {
-	if err != nil {
-		fmt.Println(err.Error())
-		os.Exit(1)
+	if err == nil {
+		return
+	}
+	var ec exitCoder
+	if errors.As(err, &ec) {
+		os.Exit(ec.ExitCode())
 	}
+	fmt.Println(err.Error())
+	os.Exit(1)
 }
@@ -1,10 +1,28 @@
 package w3c
 
 import (
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"strings"
+
 	"go.opentelemetry.io/otel/trace"
 )
 
+// errors returned when parsing a TraceParent
+var (
+	ErrInvalidTraceParent            = errors.New("invalid traceparent")
+	ErrInvalidTraceParentVersion     = errors.New("invalid traceparent version")
+	ErrUnsupportedTraceParentVersion = errors.New("unsupported traceparent version")
+	ErrInvalidTraceID                = errors.New("invalid traceparent trace-id")
+	ErrInvalidParentID               = errors.New("invalid traceparent parent-id")
+	ErrInvalidTraceFlags             = errors.New("invalid traceparent trace-flags")
+)
+
+// traceParentLength is the length of a version 00 traceparent; later versions may append
+// "-" separated fields after it
+const traceParentLength = 55
+
 // TraceParent implements the W3C trace-context standard: https://w3c.github.io/trace-context/
 type TraceParent struct {
 	ContextVersion string
@@ -23,6 +41,81 @@ func NewTraceParentFromSpanContext(ctx trace.SpanContext) TraceParent {
 	}
 }
 
+// ParseTraceParent parses and validates a traceparent header value; a version above 00 is
+// parsed as far as version 00 defines it and any fields it appends are ignored, as the spec
+// requires for forward compatibility
+func ParseTraceParent(s string) (TraceParent, error) {
+	if len(s) < traceParentLength || (len(s) > traceParentLength && s[traceParentLength] != '-') {
+		return TraceParent{}, fmt.Errorf("%w: '%s'", ErrInvalidTraceParent, s)
+	}
+	parts := strings.Split(s[:traceParentLength], "-")
+	if len(parts) != 4 {
+		return TraceParent{}, fmt.Errorf("%w: '%s'", ErrInvalidTraceParent, s)
+	}
+
+	version, err := decodeLowerHex(parts[0], 1)
+	if err != nil {
+		return TraceParent{}, fmt.Errorf("%w: '%s'", ErrInvalidTraceParentVersion, parts[0])
+	}
+	if version[0] > MaxVersion {
+		return TraceParent{}, fmt.Errorf("%w: '%s'", ErrUnsupportedTraceParentVersion, parts[0])
+	}
+	if version[0] == SupportedVersion && len(s) != traceParentLength {
+		return TraceParent{}, fmt.Errorf("%w: version %s must have exactly 4 fields: '%s'", ErrInvalidTraceParent, parts[0], s)
+	}
+
+	tp := TraceParent{ContextVersion: parts[0]}
+	traceID, err := decodeLowerHex(parts[1], len(tp.TraceID))
+	if err != nil {
+		return TraceParent{}, fmt.Errorf("%w: '%s'", ErrInvalidTraceID, parts[1])
+	}
+	copy(tp.TraceID[:], traceID)
+	if !tp.TraceID.IsValid() {
+		return TraceParent{}, fmt.Errorf("%w: must not be all zeros", ErrInvalidTraceID)
+	}
+
+	parentID, err := decodeLowerHex(parts[2], len(tp.ParentID))
+	if err != nil {
+		return TraceParent{}, fmt.Errorf("%w: '%s'", ErrInvalidParentID, parts[2])
+	}
+	copy(tp.ParentID[:], parentID)
+	if !tp.ParentID.IsValid() {
+		return TraceParent{}, fmt.Errorf("%w: must not be all zeros", ErrInvalidParentID)
+	}
+
+	flags, err := decodeLowerHex(parts[3], 1)
+	if err != nil {
+		return TraceParent{}, fmt.Errorf("%w: '%s'", ErrInvalidTraceFlags, parts[3])
+	}
+	tp.TraceFlags = trace.TraceFlags(flags[0])
+	return tp, nil
+}
+
+// decodeLowerHex decodes exactly n bytes written as lowercase hex, which is the only case
+// the spec allows
+func decodeLowerHex(s string, n int) ([]byte, error) {
+	if len(s) != 2*n || strings.ToLower(s) != s {
+		return nil, fmt.Errorf("want %d lowercase hex digits", 2*n)
+	}
+	return hex.DecodeString(s)
+}
+
+// Sampled reports whether the sampled flag is set; it is the only flag version 00 defines
+func (t TraceParent) Sampled() bool {
+	return t.TraceFlags.IsSampled()
+}
+
+// SpanContext returns the remote trace.SpanContext t describes; flags other than sampled
+// are dropped because OpenTelemetry does not know them
+func (t TraceParent) SpanContext() trace.SpanContext {
+	return trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    t.TraceID,
+		SpanID:     t.ParentID,
+		TraceFlags: t.TraceFlags & trace.FlagsSampled,
+		Remote:     true,
+	})
+}
+
 // String implements the Stringer interface for TraceParent
 func (t TraceParent) String() string {
 	return fmt.Sprintf("%s-%s-%s-%s", t.ContextVersion, t.TraceID.String(), t.ParentID.String(), t.TraceFlags.String())
@@ -0,0 +1,70 @@
+package w3c
+
+import (
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		want        string
+		wantSampled bool
+		wantErr     error
+	}{
+		{name: "sampled", in: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", want: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", wantSampled: true},
+		{name: "not sampled", in: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", want: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"},
+		{name: "unknown flags are kept", in: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-09", want: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-09", wantSampled: true},
+		{name: "future version", in: "cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", want: "cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", wantSampled: true},
+		{name: "future version with more fields", in: "cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-what-the-future-holds", want: "cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", wantSampled: true},
+		{name: "future version without separator", in: "cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01x", wantErr: ErrInvalidTraceParent},
+		{name: "version 00 with more fields", in: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", wantErr: ErrInvalidTraceParent},
+		{name: "version ff", in: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", wantErr: ErrUnsupportedTraceParentVersion},
+		{name: "invalid version", in: "0x-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", wantErr: ErrInvalidTraceParentVersion},
+		{name: "empty", in: "", wantErr: ErrInvalidTraceParent},
+		{name: "too short", in: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-1", wantErr: ErrInvalidTraceParent},
+		{name: "wrong separators", in: "00_4bf92f3577b34da6a3ce929d0e0e4736_00f067aa0ba902b7_01", wantErr: ErrInvalidTraceParent},
+		{name: "uppercase trace-id", in: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", wantErr: ErrInvalidTraceID},
+		{name: "all-zero trace-id", in: "00-00000000000000000000000000000000-00f067aa0ba902b7-01", wantErr: ErrInvalidTraceID},
+		{name: "invalid parent-id", in: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902bz-01", wantErr: ErrInvalidParentID},
+		{name: "all-zero parent-id", in: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", wantErr: ErrInvalidParentID},
+		{name: "invalid flags", in: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-0g", wantErr: ErrInvalidTraceFlags},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp, err := ParseTraceParent(tt.in)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseTraceParent() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := tp.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+			if got := tp.Sampled(); got != tt.wantSampled {
+				t.Errorf("Sampled() = %v, want %v", got, tt.wantSampled)
+			}
+		})
+	}
+}
+
+func TestTraceParent_SpanContext(t *testing.T) {
+	tp, err := ParseTraceParent("cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-09")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc := tp.SpanContext()
+	if !sc.IsValid() || !sc.IsRemote() {
+		t.Errorf("SpanContext() valid = %v, remote = %v, want both", sc.IsValid(), sc.IsRemote())
+	}
+	if sc.TraceFlags() != trace.FlagsSampled {
+		t.Errorf("SpanContext() flags = %s, want %s", sc.TraceFlags(), trace.FlagsSampled)
+	}
+	if got, want := NewTraceParentFromSpanContext(sc).String(), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"; got != want {
+		t.Errorf("round trip = %q, want %q", got, want)
+	}
+}
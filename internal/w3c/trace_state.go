@@ -0,0 +1,147 @@
+package w3c
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// MaxTraceStateMembers is the most list-members a tracestate may hold
+const MaxTraceStateMembers = 32
+
+// errors returned when parsing or building a TraceState
+var (
+	ErrInvalidTraceStateKey     = errors.New("invalid tracestate key")
+	ErrInvalidTraceStateValue   = errors.New("invalid tracestate value")
+	ErrInvalidTraceStateMember  = errors.New("invalid tracestate list-member")
+	ErrDuplicateTraceStateKey   = errors.New("duplicate tracestate key")
+	ErrTooManyTraceStateMembers = fmt.Errorf("tracestate has more than %d list-members", MaxTraceStateMembers)
+)
+
+// from: https://www.w3.org/TR/trace-context/#key and https://www.w3.org/TR/trace-context/#value
+var (
+	traceStateKeyPattern   = regexp.MustCompile(`^(?:[a-z][a-z0-9_\-*/]{0,255}|[a-z0-9][a-z0-9_\-*/]{0,240}@[a-z][a-z0-9_\-*/]{0,13})$`)
+	traceStateValuePattern = regexp.MustCompile(`^[\x20-\x2b\x2d-\x3c\x3e-\x7e]{0,255}[\x21-\x2b\x2d-\x3c\x3e-\x7e]$`)
+)
+
+// TraceStateMember is one key=value list-member of a tracestate
+type TraceStateMember struct {
+	Key   string
+	Value string
+}
+
+// TraceState implements the W3C tracestate header: https://www.w3.org/TR/trace-context/#tracestate-header
+// Members are ordered most recently updated first.
+type TraceState struct {
+	members []TraceStateMember
+}
+
+// NewTraceStateMember validates a key and value
+func NewTraceStateMember(key, value string) (TraceStateMember, error) {
+	if !traceStateKeyPattern.MatchString(key) {
+		return TraceStateMember{}, fmt.Errorf("%w: '%s'", ErrInvalidTraceStateKey, key)
+	}
+	if !traceStateValuePattern.MatchString(value) {
+		return TraceStateMember{}, fmt.Errorf("%w: '%s'", ErrInvalidTraceStateValue, value)
+	}
+	return TraceStateMember{Key: key, Value: value}, nil
+}
+
+// ParseTraceState parses a tracestate header value; empty list-members are ignored
+func ParseTraceState(s string) (TraceState, error) {
+	ts := TraceState{}
+	seen := make(map[string]bool)
+	for _, raw := range strings.Split(s, ",") {
+		raw = strings.Trim(raw, " \t")
+		if raw == "" {
+			continue
+		}
+		parts := strings.SplitN(raw, "=", 2)
+		if len(parts) != 2 {
+			return TraceState{}, fmt.Errorf("%w: '%s'", ErrInvalidTraceStateMember, raw)
+		}
+		m, err := NewTraceStateMember(parts[0], parts[1])
+		if err != nil {
+			return TraceState{}, err
+		}
+		if seen[m.Key] {
+			return TraceState{}, fmt.Errorf("%w: '%s'", ErrDuplicateTraceStateKey, m.Key)
+		}
+		seen[m.Key] = true
+		ts.members = append(ts.members, m)
+	}
+	if len(ts.members) > MaxTraceStateMembers {
+		return TraceState{}, ErrTooManyTraceStateMembers
+	}
+	return ts, nil
+}
+
+// NewTraceStateFromSpanContext creates a TraceState from the given trace.SpanContext
+func NewTraceStateFromSpanContext(ctx trace.SpanContext) TraceState {
+	// the SDK only holds valid tracestates so this cannot fail
+	ts, _ := ParseTraceState(ctx.TraceState().String())
+	return ts
+}
+
+// String implements the Stringer interface for TraceState
+func (ts TraceState) String() string {
+	members := make([]string, len(ts.members))
+	for i, m := range ts.members {
+		members[i] = m.Key + "=" + m.Value
+	}
+	return strings.Join(members, ",")
+}
+
+// Members returns the list-members, most recently updated first
+func (ts TraceState) Members() []TraceStateMember {
+	return append([]TraceStateMember(nil), ts.members...)
+}
+
+// Len returns the number of list-members
+func (ts TraceState) Len() int {
+	return len(ts.members)
+}
+
+// Get returns the value for key, or "" if there is none
+func (ts TraceState) Get(key string) string {
+	for _, m := range ts.members {
+		if m.Key == key {
+			return m.Value
+		}
+	}
+	return ""
+}
+
+// Insert returns a copy of ts with key set to value as its first list-member; as the spec
+// requires, an existing member with the same key is replaced and the right-most member is
+// dropped when the list would grow beyond MaxTraceStateMembers
+func (ts TraceState) Insert(key, value string) (TraceState, error) {
+	m, err := NewTraceStateMember(key, value)
+	if err != nil {
+		return ts, err
+	}
+	members := append([]TraceStateMember{m}, ts.Delete(key).members...)
+	if len(members) > MaxTraceStateMembers {
+		members = members[:MaxTraceStateMembers]
+	}
+	return TraceState{members: members}, nil
+}
+
+// Delete returns a copy of ts without key
+func (ts TraceState) Delete(key string) TraceState {
+	members := make([]TraceStateMember, 0, len(ts.members))
+	for _, m := range ts.members {
+		if m.Key != key {
+			members = append(members, m)
+		}
+	}
+	return TraceState{members: members}
+}
+
+// OpenTelemetry converts ts into the OpenTelemetry API's trace.TraceState
+func (ts TraceState) OpenTelemetry() (trace.TraceState, error) {
+	return trace.ParseTraceState(ts.String())
+}
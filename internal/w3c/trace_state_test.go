@@ -0,0 +1,67 @@
+package w3c
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseTraceState(t *testing.T) {
+	tooMany := make([]string, MaxTraceStateMembers+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("k%d=v", i)
+	}
+	tests := []struct {
+		name    string
+		in      string
+		want    string
+		wantErr error
+	}{
+		{name: "empty", in: "", want: ""},
+		{name: "single", in: "congo=t61rcWkgMzE", want: "congo=t61rcWkgMzE"},
+		{name: "whitespace and empty members", in: " rojo=00f067aa0ba902b7 ,, congo=t61rcWkgMzE\t", want: "rojo=00f067aa0ba902b7,congo=t61rcWkgMzE"},
+		{name: "multi-tenant key", in: "fw529a3039@dt=abc", want: "fw529a3039@dt=abc"},
+		{name: "value with spaces", in: "k=a b", want: "k=a b"},
+		{name: "uppercase key", in: "Congo=x", wantErr: ErrInvalidTraceStateKey},
+		{name: "key too long", in: strings.Repeat("a", 257) + "=x", wantErr: ErrInvalidTraceStateKey},
+		{name: "value with equals", in: "k=a=b", wantErr: ErrInvalidTraceStateValue},
+		{name: "value ends with space", in: "k=a ,j=b", want: "k=a,j=b"},
+		{name: "empty value", in: "k=", wantErr: ErrInvalidTraceStateValue},
+		{name: "missing equals", in: "k", wantErr: ErrInvalidTraceStateMember},
+		{name: "duplicate key", in: "k=a,k=b", wantErr: ErrDuplicateTraceStateKey},
+		{name: "max members", in: strings.Join(tooMany[:MaxTraceStateMembers], ","), want: strings.Join(tooMany[:MaxTraceStateMembers], ",")},
+		{name: "too many members", in: strings.Join(tooMany, ","), wantErr: ErrTooManyTraceStateMembers},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts, err := ParseTraceState(tt.in)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseTraceState() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && ts.String() != tt.want {
+				t.Errorf("ParseTraceState().String() = %q, want %q", ts.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestTraceState_Insert(t *testing.T) {
+	ts, _ := ParseTraceState("a=1,b=2")
+	ts, err := ts.Insert("b", "3")
+	if err != nil || ts.String() != "b=3,a=1" {
+		t.Errorf("Insert() existing key = (%q, %v), want b=3,a=1", ts.String(), err)
+	}
+	if _, err := ts.Insert("B", "3"); !errors.Is(err, ErrInvalidTraceStateKey) {
+		t.Errorf("Insert() invalid key error = %v, want %v", err, ErrInvalidTraceStateKey)
+	}
+
+	full := TraceState{}
+	for i := 0; i < MaxTraceStateMembers; i++ {
+		full, _ = full.Insert(fmt.Sprintf("k%d", i), "v")
+	}
+	full, err = full.Insert("new", "v")
+	if err != nil || full.Len() != MaxTraceStateMembers || full.Members()[0].Key != "new" || full.Get("k0") != "" {
+		t.Errorf("Insert() into a full tracestate should drop the right-most member, got %q (%v)", full.String(), err)
+	}
+}